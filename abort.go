@@ -0,0 +1,103 @@
+package mahjong
+
+import (
+	"sort"
+	"time"
+)
+
+// abort ends a round in an abortive draw, which is scored like any other draw.
+func (r *Round) abort(seat int, t time.Time, reason ResultReason, tiles ...Tile) {
+	r.emit(
+		newEvent(EventAbort, seat, t, tiles...),
+		Event{
+			Type: EventResult,
+			Seat: seat,
+			Time: timeInMillis(t),
+			Result: &Result{
+				Dealer: r.Dealer,
+				Wind:   r.Wind,
+				Streak: r.Streak,
+				Winner: -1,
+				Loser:  -1,
+				Reason: reason,
+			},
+		},
+	)
+}
+
+// fourKongs reports whether the round must be aborted because four kongs have
+// been declared and they do not all belong to the same player. The round is
+// aborted before a replacement tile is drawn for the fourth kong.
+func (r *Round) fourKongs() bool {
+	if !r.Rules.AbortOnFourKongs {
+		return false
+	}
+	kongs, players := 0, 0
+	for _, hand := range r.Hands {
+		n := 0
+		for _, meld := range hand.Revealed {
+			if meld.Type == MeldGang {
+				n++
+			}
+		}
+		if n > 0 {
+			kongs += n
+			players++
+		}
+	}
+	return kongs >= 4 && players > 1
+}
+
+// fourWinds reports whether the round must be aborted because every player
+// has discarded the same wind on the first go-around without any tiles being
+// claimed.
+func (r *Round) fourWinds() bool {
+	if !r.Rules.AbortOnFourWinds || len(r.Discards) != 4 {
+		return false
+	}
+	for _, hand := range r.Hands {
+		if len(hand.Revealed) > 0 {
+			return false
+		}
+	}
+	wind := r.Discards[0]
+	if wind.Suit() != SuitWinds {
+		return false
+	}
+	for _, tile := range r.Discards[1:] {
+		if tile != wind {
+			return false
+		}
+	}
+	return true
+}
+
+// nineTerminals returns the different terminals and honours in a hand.
+func nineTerminals(hand Hand) []Tile {
+	var tiles []Tile
+	for tile := range hand.Concealed {
+		if isTerminalOrHonour(tile) {
+			tiles = append(tiles, tile)
+		}
+	}
+	sort.Slice(tiles, func(i, j int) bool {
+		return tiles[i] < tiles[j]
+	})
+	return tiles
+}
+
+// checkNineTerminals aborts a round after the deal if a player, starting from
+// the dealer, holds nine or more different terminals and honours. Their
+// terminals and honours are revealed.
+func (r *Round) checkNineTerminals(t time.Time) {
+	if !r.Rules.AbortOnNineTerminals {
+		return
+	}
+	for i := 0; i < 4; i++ {
+		seat := (r.Dealer + i) % 4
+		if tiles := nineTerminals(r.Hands[seat]); len(tiles) >= 9 {
+			r.abort(seat, t, ReasonNineTerminals, tiles...)
+			return
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package mahjong
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRound_fourKongs(t *testing.T) {
+	gangs := func(n int) []Meld {
+		var melds []Meld
+		for i := 0; i < n; i++ {
+			melds = append(melds, Meld{Type: MeldGang, Tiles: []Tile{TileDots1}})
+		}
+		return melds
+	}
+	newRound := func(rules Rules) *Round {
+		return &Round{
+			Rules:    rules,
+			Wall:     []Tile{TileCharacters1},
+			DeadWall: []Tile{TileDots4, TileDots5},
+			Turn:     0,
+			Phase:    PhaseDiscard,
+			Hands: [4]Hand{
+				{Concealed: TileBag{TileDragonsRed: 4}},
+				{Revealed: gangs(2)},
+				{Revealed: gangs(1)},
+				{},
+			},
+		}
+	}
+	t.Run("fourth kong by different players aborts the round", func(t *testing.T) {
+		r := newRound(Rules{AbortOnFourKongs: true})
+		now := time.Now()
+		err := r.GangFromHand(0, now, TileDragonsRed)
+		assert.NoError(t, err)
+		assert.True(t, r.Finished)
+		assert.Equal(t, &Result{
+			Winner: -1,
+			Loser:  -1,
+			Reason: ReasonFourKongs,
+		}, r.Result)
+		assert.Equal(t, newEvent(EventAbort, 0, now), r.Events[1])
+		assert.Equal(t, []Tile{TileDots4, TileDots5}, r.DeadWall, "no replacement tile is drawn")
+	})
+	t.Run("four kongs by the same player do not abort the round", func(t *testing.T) {
+		r := newRound(Rules{AbortOnFourKongs: true})
+		r.Hands[0].Revealed = gangs(3)
+		r.Hands[1].Revealed = nil
+		r.Hands[2].Revealed = nil
+		err := r.GangFromHand(0, time.Now(), TileDragonsRed)
+		assert.NoError(t, err)
+		assert.False(t, r.Finished)
+	})
+	t.Run("rule disabled", func(t *testing.T) {
+		r := newRound(Rules{})
+		err := r.GangFromHand(0, time.Now(), TileDragonsRed)
+		assert.NoError(t, err)
+		assert.False(t, r.Finished)
+	})
+}
+
+func TestRound_fourWinds(t *testing.T) {
+	newRound := func(rules Rules, last Tile) *Round {
+		return &Round{
+			Rules:    rules,
+			Wall:     []Tile{TileCharacters1},
+			Discards: []Tile{TileWindsEast, TileWindsEast, TileWindsEast},
+			Turn:     3,
+			Phase:    PhaseDiscard,
+			Hands: [4]Hand{
+				3: {Concealed: TileBag{last: 1}},
+			},
+		}
+	}
+	t.Run("same wind discarded by everyone aborts the round", func(t *testing.T) {
+		r := newRound(Rules{AbortOnFourWinds: true}, TileWindsEast)
+		now := time.Now()
+		err := r.Discard(3, now, TileWindsEast)
+		assert.NoError(t, err)
+		assert.True(t, r.Finished)
+		assert.Equal(t, ReasonFourWinds, r.Result.Reason)
+		assert.Equal(t, newEvent(EventAbort, 3, now, TileWindsEast), r.Events[1])
+	})
+	t.Run("different last discard", func(t *testing.T) {
+		r := newRound(Rules{AbortOnFourWinds: true}, TileWindsSouth)
+		err := r.Discard(3, time.Now(), TileWindsSouth)
+		assert.NoError(t, err)
+		assert.False(t, r.Finished)
+	})
+	t.Run("rule disabled", func(t *testing.T) {
+		r := newRound(Rules{}, TileWindsEast)
+		err := r.Discard(3, time.Now(), TileWindsEast)
+		assert.NoError(t, err)
+		assert.False(t, r.Finished)
+	})
+}
+
+func TestRound_checkNineTerminals(t *testing.T) {
+	hand := TileBag{
+		TileDots1: 1, TileDots9: 1, TileBamboo1: 1, TileBamboo9: 1,
+		TileCharacters1: 1, TileWindsEast: 1, TileWindsSouth: 1, TileDragonsRed: 1,
+		TileDragonsWhite: 2, TileDots5: 3,
+	}
+	t.Run("nine different terminals and honours abort the round", func(t *testing.T) {
+		r := &Round{
+			Rules:  Rules{AbortOnNineTerminals: true},
+			Dealer: 1,
+			Hands:  [4]Hand{2: {Concealed: hand}},
+		}
+		now := time.Now()
+		r.checkNineTerminals(now)
+		assert.True(t, r.Finished)
+		assert.Equal(t, ReasonNineTerminals, r.Result.Reason)
+		assert.Equal(t, newEvent(EventAbort, 2, now,
+			TileDots1, TileDots9, TileBamboo1, TileBamboo9, TileCharacters1,
+			TileWindsEast, TileWindsSouth, TileDragonsRed, TileDragonsWhite,
+		), r.Events[0])
+	})
+	t.Run("eight different terminals and honours", func(t *testing.T) {
+		concealed := TileBag{}
+		for tile, count := range hand {
+			concealed[tile] = count
+		}
+		concealed.RemoveN(TileDragonsWhite, 2)
+		r := &Round{
+			Rules: Rules{AbortOnNineTerminals: true},
+			Hands: [4]Hand{{Concealed: concealed}},
+		}
+		r.checkNineTerminals(time.Now())
+		assert.False(t, r.Finished)
+	})
+}
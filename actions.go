@@ -0,0 +1,123 @@
+package mahjong
+
+import (
+	"sort"
+	"time"
+)
+
+// ActionType represents the type of an action a player can take.
+type ActionType string
+
+// Possible action types.
+const (
+	ActionDraw    ActionType = "draw"
+	ActionDiscard ActionType = "discard"
+	ActionChi     ActionType = "chi"
+	ActionPong    ActionType = "pong"
+	ActionGang    ActionType = "gang"
+	ActionHu      ActionType = "hu"
+	ActionEnd     ActionType = "end"
+	ActionUndo    ActionType = "undo"
+)
+
+// Action represents an action a player can take.
+type Action struct {
+	Type ActionType `json:"type"`
+
+	// Tiles are the tiles from a player's hand used in an action: the tile to
+	// discard, the two tiles to chi with or the tile to gang from hand. A gang
+	// without tiles is a gang from the last discard.
+	Tiles []Tile `json:"tiles,omitempty"`
+
+	// Points is how much a winning hand is worth for a hu action.
+	Points int `json:"points,omitempty"`
+}
+
+func sortedTiles(bag TileBag) []Tile {
+	tiles := make([]Tile, 0, len(bag))
+	for tile := range bag {
+		tiles = append(tiles, tile)
+	}
+	sort.Slice(tiles, func(i, j int) bool {
+		return tiles[i] < tiles[j]
+	})
+	return tiles
+}
+
+// LegalActions returns every action a player can take at a certain time.
+func (r *Round) LegalActions(seat int, t time.Time) []Action {
+	if seat < 0 || 3 < seat {
+		return nil
+	}
+	actions := []Action{}
+	hand := r.Hands[seat]
+	if r.canDraw(seat, t) == nil {
+		actions = append(actions, Action{Type: ActionDraw})
+	}
+	if len(r.Discards) > 0 {
+		tile0 := r.lastDiscard()
+		for _, pair := range sequences[tile0] {
+			if r.canChi(seat, t, pair[0], pair[1]) == nil {
+				actions = append(actions, Action{Type: ActionChi, Tiles: []Tile{pair[0], pair[1]}})
+			}
+		}
+	}
+	if r.canPong(seat) == nil {
+		actions = append(actions, Action{Type: ActionPong})
+	}
+	if r.canGangFromDiscard(seat) == nil {
+		actions = append(actions, Action{Type: ActionGang})
+	}
+	for _, tile := range sortedTiles(hand.Concealed) {
+		if r.canGangFromHand(seat, tile) == nil {
+			actions = append(actions, Action{Type: ActionGang, Tiles: []Tile{tile}})
+		}
+	}
+	if _, points, _, err := r.hu(seat, t); err == nil {
+		actions = append(actions, Action{Type: ActionHu, Points: points})
+	}
+	for _, tile := range sortedTiles(hand.Concealed) {
+		if r.canDiscard(seat, tile) == nil {
+			actions = append(actions, Action{Type: ActionDiscard, Tiles: []Tile{tile}})
+		}
+	}
+	if r.canEnd(seat) == nil {
+		actions = append(actions, Action{Type: ActionEnd})
+	}
+	if r.CanUndo(seat, t) == nil {
+		actions = append(actions, Action{Type: ActionUndo})
+	}
+	return actions
+}
+
+// Act takes an action for a player, such as one returned by LegalActions.
+func (r *Round) Act(seat int, t time.Time, action Action) error {
+	switch action.Type {
+	case ActionDraw:
+		return r.Draw(seat, t)
+	case ActionDiscard:
+		if len(action.Tiles) < 1 {
+			return newActionError(seat, action.Type, ErrMissingTiles)
+		}
+		return r.Discard(seat, t, action.Tiles[0])
+	case ActionChi:
+		if len(action.Tiles) < 2 {
+			return newActionError(seat, action.Type, ErrMissingTiles)
+		}
+		return r.Chi(seat, t, action.Tiles[0], action.Tiles[1])
+	case ActionPong:
+		return r.Pong(seat, t)
+	case ActionGang:
+		if len(action.Tiles) > 0 {
+			return r.GangFromHand(seat, t, action.Tiles[0])
+		}
+		return r.GangFromDiscard(seat, t)
+	case ActionHu:
+		return r.Hu(seat, t)
+	case ActionEnd:
+		return r.End(seat, t)
+	case ActionUndo:
+		return r.Undo(seat, t)
+	}
+	return newActionError(seat, action.Type, ErrUnknownAction)
+}
@@ -0,0 +1,144 @@
+package mahjong
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRound_LegalActions(t *testing.T) {
+	t.Run("bystanders cannot take any actions", func(t *testing.T) {
+		r := &Round{Turn: 0, Phase: PhaseDraw}
+		assert.Nil(t, r.LegalActions(-1, time.Now()))
+	})
+	t.Run("draw, chi, pong and gang after a discard", func(t *testing.T) {
+		r := &Round{
+			Wall:     make([]Tile, 1),
+			Turn:     1,
+			Phase:    PhaseDraw,
+			Discards: []Tile{TileBamboo3},
+			Hands: [4]Hand{{}, {
+				Concealed: NewTileBag([]Tile{
+					TileBamboo1, TileBamboo2, TileBamboo3, TileBamboo3, TileBamboo3, TileBamboo4, TileBamboo5, TileWindsEast,
+				}),
+			}},
+		}
+		actions := r.LegalActions(1, time.Now())
+		assert.Equal(t, []Action{
+			{Type: ActionDraw},
+			{Type: ActionChi, Tiles: []Tile{TileBamboo1, TileBamboo2}},
+			{Type: ActionChi, Tiles: []Tile{TileBamboo2, TileBamboo4}},
+			{Type: ActionChi, Tiles: []Tile{TileBamboo4, TileBamboo5}},
+			{Type: ActionPong},
+			{Type: ActionGang},
+		}, actions)
+	})
+	t.Run("only pong and gang for other players", func(t *testing.T) {
+		r := &Round{
+			Turn:     1,
+			Phase:    PhaseDraw,
+			Discards: []Tile{TileDragonsRed},
+			Hands: [4]Hand{{}, {}, {
+				Concealed: NewTileBag([]Tile{TileDragonsRed, TileDragonsRed}),
+			}},
+		}
+		assert.Equal(t, []Action{{Type: ActionPong}}, r.LegalActions(2, time.Now()))
+		assert.Equal(t, []Action{}, r.LegalActions(3, time.Now()))
+	})
+	t.Run("no draw or chi during reserved duration", func(t *testing.T) {
+		now := time.Now()
+		r := &Round{
+			Turn:             1,
+			Phase:            PhaseDraw,
+			Discards:         []Tile{TileBamboo3},
+			LastActionTime:   now,
+			ReservedDuration: 2 * time.Second,
+			Hands: [4]Hand{{}, {
+				Concealed: NewTileBag([]Tile{TileBamboo1, TileBamboo2}),
+			}},
+		}
+		assert.Equal(t, []Action{}, r.LegalActions(1, now))
+		assert.Equal(t, []Action{
+			{Type: ActionDraw},
+			{Type: ActionChi, Tiles: []Tile{TileBamboo1, TileBamboo2}},
+		}, r.LegalActions(1, now.Add(2*time.Second)))
+	})
+	t.Run("gang, hu and discard during own discard phase", func(t *testing.T) {
+		seat := 1
+		r := &Round{
+			Wall:  make([]Tile, 1),
+			Turn:  seat,
+			Phase: PhaseDiscard,
+			Hands: [4]Hand{{},
+				{
+					Flowers:  []Tile{TileGentlemen1, TileCat},
+					Revealed: []Meld{{Type: MeldPong, Tiles: []Tile{TileDots3}}},
+					Concealed: NewTileBag([]Tile{
+						TileDots3,
+						TileBamboo6, TileBamboo7, TileBamboo8,
+						TileWindsWest, TileWindsWest, TileWindsWest,
+						TileDragonsWhite, TileDragonsWhite,
+					}),
+				},
+			},
+		}
+		assert.Equal(t, []Action{
+			{Type: ActionGang, Tiles: []Tile{TileDots3}},
+			{Type: ActionDiscard, Tiles: []Tile{TileDots3}},
+			{Type: ActionDiscard, Tiles: []Tile{TileBamboo6}},
+			{Type: ActionDiscard, Tiles: []Tile{TileBamboo7}},
+			{Type: ActionDiscard, Tiles: []Tile{TileBamboo8}},
+			{Type: ActionDiscard, Tiles: []Tile{TileWindsWest}},
+			{Type: ActionDiscard, Tiles: []Tile{TileDragonsWhite}},
+		}, r.LegalActions(seat, time.Now()))
+	})
+	t.Run("hu with points", func(t *testing.T) {
+		seat := 1
+		r := &Round{
+			Turn:  seat,
+			Phase: PhaseDiscard,
+			Hands: [4]Hand{{},
+				{
+					Flowers:  []Tile{TileGentlemen1, TileCat},
+					Revealed: []Meld{{Type: MeldChi, Tiles: []Tile{TileDots3, TileDots4, TileDots5}}},
+					Concealed: NewTileBag([]Tile{
+						TileBamboo6, TileBamboo7, TileBamboo8,
+						TileWindsWest, TileWindsWest, TileWindsWest,
+						TileCharacters8, TileCharacters8, TileCharacters8,
+						TileDragonsWhite, TileDragonsWhite,
+					}),
+				},
+			},
+		}
+		assert.Equal(t, []Action{
+			{Type: ActionHu, Points: 1},
+			{Type: ActionEnd},
+		}, r.LegalActions(seat, time.Now()))
+	})
+}
+
+func TestRound_Act(t *testing.T) {
+	t.Run("takes an action", func(t *testing.T) {
+		r := &Round{
+			Wall:  []Tile{TileBamboo1},
+			Turn:  1,
+			Phase: PhaseDraw,
+			Hands: newHands(),
+		}
+		err := r.Act(1, time.Now(), Action{Type: ActionDraw})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, r.Hands[1].Concealed.Count(TileBamboo1))
+		assert.Equal(t, PhaseDiscard, r.Phase)
+	})
+	t.Run("missing tiles", func(t *testing.T) {
+		r := &Round{Turn: 1, Phase: PhaseDiscard, Hands: newHands()}
+		err := r.Act(1, time.Now(), Action{Type: ActionDiscard})
+		assert.Equal(t, &ActionError{Seat: 1, Action: ActionDiscard, Err: ErrMissingTiles}, err)
+	})
+	t.Run("unknown action", func(t *testing.T) {
+		r := &Round{}
+		err := r.Act(0, time.Now(), Action{Type: "skip"})
+		assert.Equal(t, &ActionError{Seat: 0, Action: "skip", Err: ErrUnknownAction}, err)
+	})
+}
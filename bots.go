@@ -0,0 +1,133 @@
+package mahjong
+
+// findAction returns the first action of a certain type, or nil if there is
+// none.
+func findAction(actions []Action, actionType ActionType) *Action {
+	for i := range actions {
+		if actions[i].Type == actionType {
+			return &actions[i]
+		}
+	}
+	return nil
+}
+
+// discardChoice is a tile a player could discard and how close to winning
+// their hand would be afterwards.
+type discardChoice struct {
+	tile    Tile
+	shanten int
+	live    int
+}
+
+// better reports whether discarding one tile leaves a better hand than
+// discarding another: one closer to winning, then one which more unseen tiles
+// improve, then one without the loneliest terminals and honours.
+func (d discardChoice) better(other discardChoice) bool {
+	if d.shanten != other.shanten {
+		return d.shanten < other.shanten
+	}
+	if d.live != other.live {
+		return d.live > other.live
+	}
+	return isTerminalOrHonour(d.tile) && !isTerminalOrHonour(other.tile)
+}
+
+// bestDiscard returns the best of certain tiles to discard from a hand which
+// needs a number of melds.
+func bestDiscard(hand tileCounts, sets int, unseen map[Tile]int, candidates []Tile) discardChoice {
+	var best discardChoice
+	for i, tile := range candidates {
+		hand.add(tile, -1)
+		choice := discardChoice{
+			tile:    tile,
+			shanten: hand.shanten(sets),
+		}
+		choice.live = hand.liveTiles(sets, unseen)
+		hand.add(tile, 1)
+		if i == 0 || choice.better(best) {
+			best = choice
+		}
+	}
+	return best
+}
+
+// handTiles returns the kinds of tiles in a hand in order.
+func handTiles(hand tileCounts) []Tile {
+	var tiles []Tile
+	for i, n := range hand.kinds {
+		if n > 0 {
+			tiles = append(tiles, tileKinds[i])
+		}
+	}
+	if hand.jokers > 0 {
+		tiles = append(tiles, TileJoker)
+	}
+	return tiles
+}
+
+// GreedyBot is a Player which tries to complete its hand as quickly as
+// possible without regard for what other players are waiting for. It always
+// wins when it can, declares every gang and pongs or chis only when that
+// brings its hand closer to winning. It discards the tile which leaves its
+// hand the fewest tiles from being ready, preferring hands which more unseen
+// tiles improve.
+type GreedyBot struct{}
+
+func (GreedyBot) Decide(view RoundView, actions []Action) *Action {
+	for _, actionType := range []ActionType{ActionHu, ActionGang, ActionEnd} {
+		if action := findAction(actions, actionType); action != nil {
+			return action
+		}
+	}
+	hand := view.Hands[view.Seat]
+	counts := countTiles(hand.Concealed)
+	sets := 4 - len(hand.Revealed)
+	if claim := improvingClaim(view, counts, sets, actions); claim != nil {
+		return claim
+	}
+	if action := findAction(actions, ActionDraw); action != nil {
+		return action
+	}
+	var candidates []Tile
+	for _, action := range actions {
+		if action.Type == ActionDiscard {
+			candidates = append(candidates, action.Tiles[0])
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	best := bestDiscard(counts, sets, view.Unseen, candidates)
+	return &Action{Type: ActionDiscard, Tiles: []Tile{best.tile}}
+}
+
+// improvingClaim returns the pong or chi which brings a hand closest to
+// winning after discarding, if any does.
+func improvingClaim(view RoundView, counts tileCounts, sets int, actions []Action) *Action {
+	if len(view.Discards) == 0 {
+		return nil
+	}
+	discard := view.Discards[len(view.Discards)-1]
+	shanten := counts.shanten(sets)
+	var claim *Action
+	for i, action := range actions {
+		var used []Tile
+		switch action.Type {
+		case ActionPong:
+			used = []Tile{discard, discard}
+		case ActionChi:
+			used = action.Tiles
+		default:
+			continue
+		}
+		after := counts
+		for _, tile := range used {
+			after.add(tile, -1)
+		}
+		best := bestDiscard(after, sets-1, view.Unseen, handTiles(after))
+		if best.shanten < shanten {
+			claim, shanten = &actions[i], best.shanten
+		}
+	}
+	return claim
+}
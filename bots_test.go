@@ -0,0 +1,85 @@
+package mahjong
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGreedyBot_Decide(t *testing.T) {
+	ready := []Tile{
+		TileDots1, TileDots2, TileDots3, TileDots4, TileDots5, TileDots6, TileBamboo7, TileBamboo7, TileBamboo7,
+		TileCharacters2, TileCharacters3, TileDragonsRed, TileDragonsRed,
+	}
+	t.Run("wins when it can", func(t *testing.T) {
+		view := RoundView{Seat: 1, Hands: [4]Hand{1: {Concealed: NewTileBag(ready)}}}
+		actions := []Action{{Type: ActionDraw}, {Type: ActionHu, Points: 1}}
+		assert.Equal(t, &Action{Type: ActionHu, Points: 1}, GreedyBot{}.Decide(view, actions))
+	})
+	t.Run("declares gang", func(t *testing.T) {
+		view := RoundView{Seat: 1, Hands: [4]Hand{1: {Concealed: NewTileBag(ready)}}}
+		actions := []Action{{Type: ActionDraw}, {Type: ActionGang}}
+		assert.Equal(t, &Action{Type: ActionGang}, GreedyBot{}.Decide(view, actions))
+	})
+	t.Run("discards the loneliest tile", func(t *testing.T) {
+		tiles := append([]Tile{TileWindsNorth}, ready...)
+		view := RoundView{
+			Seat:   1,
+			Hands:  [4]Hand{1: {Concealed: NewTileBag(tiles)}},
+			Unseen: map[Tile]int{TileCharacters1: 4, TileCharacters4: 4, TileWindsNorth: 3},
+		}
+		var actions []Action
+		for _, tile := range sortedTiles(view.Hands[1].Concealed) {
+			actions = append(actions, Action{Type: ActionDiscard, Tiles: []Tile{tile}})
+		}
+		assert.Equal(t, &Action{Type: ActionDiscard, Tiles: []Tile{TileWindsNorth}}, GreedyBot{}.Decide(view, actions))
+	})
+	t.Run("pongs when it brings the hand closer", func(t *testing.T) {
+		view := RoundView{
+			Seat: 2,
+			Hands: [4]Hand{2: {Concealed: NewTileBag([]Tile{
+				TileDots1, TileDots2, TileDots3, TileDots5, TileDots9, TileBamboo1, TileBamboo5, TileBamboo9,
+				TileCharacters2, TileCharacters3, TileCharacters9, TileDragonsRed, TileDragonsRed,
+			})}},
+			Discards: []Tile{TileDragonsRed},
+		}
+		assert.Equal(t, &Action{Type: ActionPong}, GreedyBot{}.Decide(view, []Action{{Type: ActionPong}}))
+	})
+	t.Run("passes on a pong which does not help", func(t *testing.T) {
+		view := RoundView{
+			Seat:     2,
+			Hands:    [4]Hand{2: {Concealed: NewTileBag(ready)}},
+			Discards: []Tile{TileDragonsRed},
+		}
+		assert.Nil(t, GreedyBot{}.Decide(view, []Action{{Type: ActionPong}}))
+	})
+	t.Run("chis when it brings the hand closer", func(t *testing.T) {
+		view := RoundView{
+			Seat: 1,
+			Hands: [4]Hand{1: {Concealed: NewTileBag([]Tile{
+				TileDots1, TileDots2, TileDots5, TileDots9, TileBamboo1, TileBamboo5, TileBamboo9,
+				TileCharacters2, TileCharacters5, TileCharacters9, TileWindsEast, TileDragonsRed, TileDragonsRed,
+			})}},
+			Discards: []Tile{TileDots3},
+		}
+		actions := []Action{{Type: ActionDraw}, {Type: ActionChi, Tiles: []Tile{TileDots1, TileDots2}}}
+		assert.Equal(t, &actions[1], GreedyBot{}.Decide(view, actions))
+	})
+}
+
+func TestGreedyBot_wins(t *testing.T) {
+	g := NewGame(RulesDefault, time.Second, rand.New(rand.NewSource(1)))
+	g.Length.Hands = 8
+	players := [4]Player{GreedyBot{}, GreedyBot{}, GreedyBot{}, GreedyBot{}}
+	require.NoError(t, PlayGame(g, time.Unix(0, 0), players))
+	wins := 0
+	for _, result := range g.Results {
+		if result.Winner != -1 {
+			wins++
+		}
+	}
+	assert.NotZero(t, wins)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/yi-jiayu/mahjong.go"
+)
+
+var bots = map[string]func(rng *rand.Rand) mahjong.Player{
+	"random":    func(rng *rand.Rand) mahjong.Player { return randomBot{rng} },
+	"greedy":    func(rng *rand.Rand) mahjong.Player { return mahjong.GreedyBot{} },
+	"defensive": func(rng *rand.Rand) mahjong.Player { return mahjong.DefensiveBot{} },
+	"mcts": func(rng *rand.Rand) mahjong.Player {
+		return mahjong.MCTSBot{Rules: rules, Seed: rng.Int63(), Iterations: iterations}
+	},
+}
+
+func newBot(name string, rng *rand.Rand) (mahjong.Player, error) {
+	constructor, ok := bots[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown bot: %s", name)
+	}
+	return constructor(rng), nil
+}
+
+// randomBot takes any legal action at random, and passes on claims as often
+// as it takes any one of them.
+type randomBot struct {
+	rng *rand.Rand
+}
+
+func (b randomBot) Decide(view mahjong.RoundView, actions []mahjong.Action) *mahjong.Action {
+	if !mahjong.MustAct(actions) {
+		i := b.rng.Intn(len(actions) + 1)
+		if i == len(actions) {
+			return nil
+		}
+		return &actions[i]
+	}
+	return &actions[b.rng.Intn(len(actions))]
+}
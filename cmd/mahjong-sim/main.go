@@ -0,0 +1,120 @@
+// Command mahjong-sim plays games between bots using the engine directly and
+// reports statistics about the results, for tuning house rules and bots.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yi-jiayu/mahjong.go"
+)
+
+var (
+	games    int
+	seed     int64
+	botNames string
+	hands    int
+	rotate   bool
+
+	iterations int
+	rules      = mahjong.RulesDefault
+)
+
+func init() {
+	flag.IntVar(&games, "games", 100, "number of games to play")
+	flag.Int64Var(&seed, "seed", 1, "seed for shuffling and bot decisions")
+	flag.StringVar(&botNames, "bots", "greedy,greedy,random,random", "comma-separated bots for each seat: "+strings.Join(botList(), ", "))
+	flag.IntVar(&hands, "hands", 0, "maximum number of rounds per game, or 0 to play every wind")
+	flag.BoolVar(&rotate, "rotate", true, "move each bot to the next seat after every game")
+	flag.IntVar(&iterations, "mcts-iterations", 50, "rounds simulated by mcts bots for each decision")
+
+	flag.BoolVar(&rules.Shooter, "shooter", rules.Shooter, "only the player who threw the winning tile pays")
+	flag.IntVar(&rules.Limit, "limit", rules.Limit, "maximum points for a winning hand")
+	flag.BoolVar(&rules.DealerKeepsOnDraw, "dealer-keeps-on-draw", false, "keep the same dealer after a draw")
+	flag.IntVar(&rules.StreakPoints, "streak-points", 0, "points added to the dealer's winning hand for each round kept")
+	flag.BoolVar(&rules.AbortOnFourKongs, "abort-four-kongs", false, "end a round in a draw after four kongs by more than one player")
+	flag.BoolVar(&rules.AbortOnFourWinds, "abort-four-winds", false, "end a round in a draw when everyone discards the same wind first")
+	flag.BoolVar(&rules.AbortOnNineTerminals, "abort-nine-terminals", false, "end a round in a draw when a player is dealt nine terminals and honours")
+	flag.BoolVar(&rules.Liability, "liability", false, "a player who feeds a big hand pays for everyone")
+	flag.BoolVar(&rules.SacredDiscard, "sacred-discard", false, "players cannot win off a tile they already passed on")
+	flag.BoolVar(&rules.Tiles.NoAnimals, "no-animals", false, "play without animal tiles")
+	flag.IntVar(&rules.Tiles.Jokers, "jokers", 0, "number of jokers in the wall")
+}
+
+func botList() []string {
+	var names []string
+	for name := range bots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// seats returns the names of the bots in each seat for a game.
+func seats(names []string, game int) [4]string {
+	var seated [4]string
+	for seat := range seated {
+		i := seat
+		if rotate {
+			i += game
+		}
+		seated[seat] = names[i%4]
+	}
+	return seated
+}
+
+// playGame plays a game between bots and records each round.
+func playGame(rng *rand.Rand, names [4]string, s *stats) error {
+	var players [4]mahjong.Player
+	for seat, name := range names {
+		b, err := newBot(name, rng)
+		if err != nil {
+			return err
+		}
+		players[seat] = b
+	}
+	g := mahjong.NewGame(rules, time.Second, rng)
+	g.Length.Hands = hands
+	if err := g.Start(time.Unix(0, 0)); err != nil {
+		return err
+	}
+	for {
+		if err := mahjong.PlayRound(g.Round, players); err != nil {
+			return err
+		}
+		s.record(g.Round, names)
+		err := g.NextRound(g.Round.LastActionTime)
+		if errors.Is(err, mahjong.ErrNoMoreRounds) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	s.games++
+	return nil
+}
+
+func main() {
+	flag.Parse()
+	names := strings.Split(botNames, ",")
+	if len(names) != 4 {
+		fmt.Printf("error: need a bot for each of 4 seats, got %d\n", len(names))
+		os.Exit(1)
+	}
+	rng := rand.New(rand.NewSource(seed))
+	s := newStats()
+	for game := 0; game < games; game++ {
+		if err := playGame(rng, seats(names, game), s); err != nil {
+			fmt.Printf("error in game %d: %v\n", game, err)
+			os.Exit(1)
+		}
+	}
+	s.print(os.Stdout)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/yi-jiayu/mahjong.go"
+)
+
+// stats aggregates the outcomes of simulated rounds.
+type stats struct {
+	games  int
+	rounds int
+
+	seatWins  [4]int
+	selfDraws int
+	draws     map[mahjong.ResultReason]int
+
+	// botRounds and botWins are keyed by bot name. A bot occupying two
+	// seats plays two rounds for every round played.
+	botRounds  map[string]int
+	botWins    map[string]int
+	botDealIns map[string]int
+	botScores  map[string]int
+
+	points map[int]int
+	tai    map[mahjong.TaiName]int
+
+	discards    int
+	bites       int
+	bitePayouts int
+}
+
+func newStats() *stats {
+	return &stats{
+		draws:      make(map[mahjong.ResultReason]int),
+		botRounds:  make(map[string]int),
+		botWins:    make(map[string]int),
+		botDealIns: make(map[string]int),
+		botScores:  make(map[string]int),
+		points:     make(map[int]int),
+		tai:        make(map[mahjong.TaiName]int),
+	}
+}
+
+// record adds a finished round played by certain bots to the statistics.
+func (s *stats) record(r *mahjong.Round, names [4]string) {
+	s.rounds++
+	for i, e := range r.Events {
+		switch e.Type {
+		case mahjong.EventDiscard:
+			s.discards++
+		case mahjong.EventBitten:
+			s.bites++
+			if i+1 < len(r.Events) && r.Events[i+1].Type == mahjong.EventPayout {
+				s.bitePayouts += r.Events[i+1].Scores[e.Seat]
+			}
+		case mahjong.EventPayout:
+			for seat, delta := range e.Scores {
+				s.botScores[names[seat]] += delta
+			}
+		}
+	}
+	for _, name := range names {
+		s.botRounds[name]++
+	}
+	result := r.Result
+	if result.Winner == -1 {
+		s.draws[result.Reason]++
+		return
+	}
+	s.seatWins[result.Winner]++
+	s.botWins[names[result.Winner]]++
+	if result.Loser == -1 {
+		s.selfDraws++
+	} else {
+		s.botDealIns[names[result.Loser]]++
+	}
+	s.points[result.Points]++
+	seen := make(map[mahjong.TaiName]bool)
+	for _, element := range result.Tai {
+		if !seen[element.Name] {
+			s.tai[element.Name]++
+			seen[element.Name] = true
+		}
+	}
+}
+
+func percent(n, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(n)/float64(total))
+}
+
+func average(n, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", float64(n)/float64(total))
+}
+
+// sortedByCount returns the keys of counts in descending order of count.
+func sortedByCount(keys []string, count func(string) int) []string {
+	sort.SliceStable(keys, func(i, j int) bool {
+		return count(keys[i]) > count(keys[j])
+	})
+	return keys
+}
+
+// print writes a report of the statistics.
+func (s *stats) print(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	wins := s.rounds
+	draws := 0
+	for _, n := range s.draws {
+		draws += n
+	}
+	wins -= draws
+
+	fmt.Fprintf(w, "games\t%d\n", s.games)
+	fmt.Fprintf(w, "rounds\t%d\n", s.rounds)
+	fmt.Fprintf(w, "average length\t%s discards\n", average(s.discards, s.rounds))
+	fmt.Fprintf(w, "draws\t%d\t%s\n", draws, percent(draws, s.rounds))
+	var reasons []string
+	for reason := range s.draws {
+		reasons = append(reasons, string(reason))
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		n := s.draws[mahjong.ResultReason(reason)]
+		fmt.Fprintf(w, "  %s\t%d\t%s\n", reason, n, percent(n, s.rounds))
+	}
+	fmt.Fprintf(w, "self-drawn wins\t%d\t%s\n", s.selfDraws, percent(s.selfDraws, wins))
+	fmt.Fprintf(w, "flower bites\t%d\t%s per round\n", s.bites, average(s.bites, s.rounds))
+	fmt.Fprintf(w, "bite payouts\t%d\t%s per round\n", s.bitePayouts, average(s.bitePayouts, s.rounds))
+
+	fmt.Fprintf(w, "\nseat\twins\twin rate\n")
+	for seat, n := range s.seatWins {
+		fmt.Fprintf(w, "%d\t%d\t%s\n", seat, n, percent(n, s.rounds))
+	}
+
+	fmt.Fprintf(w, "\nbot\trounds\twins\twin rate\tdeal-in rate\tscore per round\n")
+	var names []string
+	for name := range s.botRounds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rounds := s.botRounds[name]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n", name, rounds, s.botWins[name], percent(s.botWins[name], rounds), percent(s.botDealIns[name], rounds), average(s.botScores[name], rounds))
+	}
+
+	fmt.Fprintf(w, "\npoints\twins\tshare\n")
+	var points []int
+	for p := range s.points {
+		points = append(points, p)
+	}
+	sort.Ints(points)
+	for _, p := range points {
+		fmt.Fprintf(w, "%d\t%d\t%s\n", p, s.points[p], percent(s.points[p], wins))
+	}
+
+	fmt.Fprintf(w, "\ntai\twins\tshare\n")
+	var elements []string
+	for name := range s.tai {
+		elements = append(elements, string(name))
+	}
+	sort.Strings(elements)
+	elements = sortedByCount(elements, func(name string) int {
+		return s.tai[mahjong.TaiName(name)]
+	})
+	for _, name := range elements {
+		n := s.tai[mahjong.TaiName(name)]
+		fmt.Fprintf(w, "%s\t%d\t%s\n", name, n, percent(n, wins))
+	}
+	w.Flush()
+}
@@ -7,26 +7,92 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/sessions/cookie"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/yi-jiayu/mahjong.go"
 	"github.com/yi-jiayu/mahjong.go/parlour"
 )
 
 var (
 	host, port string
 	database   string
+	validate   bool
+	botAI      string
+
+	externalAIs       externalAIFlag
+	externalAITimeout time.Duration
 )
 
+// externalAIFlag collects external AIs to register as name=command.
+type externalAIFlag []string
+
+func (f *externalAIFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *externalAIFlag) Set(value string) error {
+	name, command := splitExternalAI(value)
+	if name == "" || len(command) == 0 {
+		return fmt.Errorf("expected name=command: %s", value)
+	}
+	*f = append(*f, value)
+	// registering it now lets the usage of -bot-ai list it, and the timeout
+	// is only read once a bot needs it since it may be set by a later flag
+	parlour.RegisterAI(name, func(rules mahjong.Rules) parlour.AI {
+		return parlour.ExternalAI(externalAITimeout, command[0], command[1:]...)(rules)
+	})
+	return nil
+}
+
+// splitExternalAI splits an external AI flag into its name and the command
+// to run it with.
+func splitExternalAI(value string) (string, []string) {
+	i := strings.Index(value, "=")
+	if i < 0 {
+		return "", nil
+	}
+	return value[:i], strings.Fields(value[i+1:])
+}
+
 func init() {
 	flag.StringVar(&host, "host", "localhost", "host to listen on")
 	flag.StringVar(&port, "port", "8080", "port to listen on")
 	flag.StringVar(&database, "database", "", "database url")
+	flag.BoolVar(&validate, "validate", false, "check rounds for inconsistencies after every action")
+	flag.StringVar(&botAI, "bot-ai", parlour.DefaultAI, "")
+	flag.Var(&externalAIs, "external-ai", "external ai to register as name=command, may be repeated")
+	flag.DurationVar(&externalAITimeout, "external-ai-timeout", parlour.DefaultExternalTimeout, "how long external ais have to respond")
+
+	flag.Usage = usage
 
 	rand.Seed(time.Now().UnixNano())
 }
 
+// usage prints the usage of every flag, listing the ais registered so far
+// for -bot-ai.
+func usage() {
+	flag.Lookup("bot-ai").Usage = botAIUsage()
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func botAIUsage() string {
+	return "ai bots are added with when none is chosen: " + strings.Join(parlour.AINames(), ", ")
+}
+
+// isAI reports whether name is the name of a registered ai.
+func isAI(name string) bool {
+	for _, ai := range parlour.AINames() {
+		if ai == name {
+			return true
+		}
+	}
+	return false
+}
+
 func getKey(name string) []byte {
 	authKey, err := base64.StdEncoding.DecodeString(os.Getenv(name))
 	if err != nil {
@@ -41,6 +107,11 @@ func getKey(name string) []byte {
 }
 
 func main() {
+	flag.Parse()
+	if !isAI(botAI) {
+		fmt.Printf("error: unknown ai: %s, expected one of %s\n", botAI, strings.Join(parlour.AINames(), ", "))
+		os.Exit(1)
+	}
 	pool, err := pgxpool.Connect(context.Background(), database)
 	if err != nil {
 		fmt.Printf("error connecting to postgres: %v", err)
@@ -51,6 +122,8 @@ func main() {
 	encKey := getKey("PARLOUR_SESSION_ENC_KEY")
 	store := cookie.NewStore(authKey, encKey)
 	p := parlour.New(roomRepository, store)
+	p.ValidateRounds = validate
+	p.DefaultAI = botAI
 	err = p.Run(host + ":" + port)
 	if err != nil {
 		fmt.Printf("error: %v\n", err)
@@ -0,0 +1,203 @@
+package mahjong
+
+import "sort"
+
+// DefensiveBot is a Player which plays like GreedyBot while its hand is close
+// to winning or nobody seems close to winning, but otherwise gives up on its
+// hand to avoid discarding tiles which other players might win off.
+type DefensiveBot struct{}
+
+func (DefensiveBot) Decide(view RoundView, actions []Action) *Action {
+	for _, actionType := range []ActionType{ActionHu, ActionEnd} {
+		if action := findAction(actions, actionType); action != nil {
+			return action
+		}
+	}
+	hand := view.Hands[view.Seat]
+	counts := countTiles(hand.Concealed)
+	sets := 4 - len(hand.Revealed)
+	threats := opponentThreats(view)
+	if !shouldDefend(counts.shanten(sets), threats) {
+		return GreedyBot{}.Decide(view, actions)
+	}
+	// a defending player keeps their concealed tiles to discard instead of
+	// claiming anything
+	if action := findAction(actions, ActionDraw); action != nil {
+		return action
+	}
+	var candidates []Tile
+	for _, action := range actions {
+		if action.Type == ActionDiscard {
+			candidates = append(candidates, action.Tiles[0])
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	return &Action{Type: ActionDiscard, Tiles: []Tile{safestDiscard(view, threats, counts, sets, candidates)}}
+}
+
+// shouldDefend reports whether a player a number of tiles away from being
+// ready should stop trying to win given how threatening each other player is.
+func shouldDefend(shanten int, threats [4]float64) bool {
+	threat := 0.0
+	for _, t := range threats {
+		if t > threat {
+			threat = t
+		}
+	}
+	switch {
+	case shanten <= 0:
+		return false
+	case shanten == 1:
+		return threat >= 2
+	default:
+		return threat >= 1
+	}
+}
+
+// safestDiscard returns the least dangerous of certain tiles to discard,
+// preferring the one which keeps a hand closest to winning among tiles which
+// are about as dangerous as each other.
+func safestDiscard(view RoundView, threats [4]float64, hand tileCounts, sets int, candidates []Tile) Tile {
+	dangers := make(map[Tile]float64, len(candidates))
+	for _, tile := range candidates {
+		for seat, threat := range threats {
+			if threat > 0 {
+				dangers[tile] += threat * tileDanger(view, seat, tile)
+			}
+		}
+	}
+	sorted := append([]Tile(nil), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return dangers[sorted[i]] < dangers[sorted[j]]
+	})
+	safe := sorted[:1]
+	for _, tile := range sorted[1:] {
+		if dangers[tile]-dangers[sorted[0]] < 0.05 {
+			safe = append(safe, tile)
+		}
+	}
+	return bestDiscard(hand, sets, view.Unseen, safe).tile
+}
+
+// opponentThreats estimates how likely each other player is to be close to
+// winning, weighted by how much their hand looks to be worth. The viewing
+// player poses no threat to themselves.
+func opponentThreats(view RoundView) [4]float64 {
+	var threats [4]float64
+	for seat, hand := range view.Hands {
+		if seat == view.Seat || hand.Dead {
+			continue
+		}
+		// more revealed melds and discards mean a hand is further along
+		readiness := 0.2*float64(len(hand.Revealed)) + 0.04*float64(len(view.Rivers[seat]))
+		if view.DrawsLeft < 20 {
+			readiness += 0.2
+		}
+		if readiness > 1 {
+			readiness = 1
+		}
+		value := 1.0
+		for _, meld := range hand.Revealed {
+			if isValuablePong(view, seat, meld) {
+				value += 0.5
+			}
+		}
+		if _, ok := flushSuit(hand.Revealed); ok {
+			value += 1
+		}
+		threats[seat] = readiness * value
+	}
+	return threats
+}
+
+// isValuablePong reports whether a meld is a pong or gang of dragons or of a
+// wind matching the prevailing wind or the seat wind of the player who
+// revealed it.
+func isValuablePong(view RoundView, seat int, meld Meld) bool {
+	if meld.Type != MeldPong && meld.Type != MeldGang {
+		return false
+	}
+	tile := meld.Tiles[0]
+	seatWind := Direction((seat - view.Dealer + 4) % 4)
+	return tile.Suit() == SuitDragons || isMatchingWind(tile, view.Wind) || isMatchingWind(tile, seatWind)
+}
+
+// flushSuit returns the only numbered suit among revealed melds, if they
+// contain at least one numbered meld and no other numbered suit.
+func flushSuit(revealed Melds) (Suit, bool) {
+	var suit Suit
+	for _, meld := range revealed {
+		s := meld.Tiles[0].Suit()
+		switch s {
+		case SuitDots, SuitBamboo, SuitCharacters:
+			if suit != 0 && suit != s {
+				return 0, false
+			}
+			suit = s
+		}
+	}
+	return suit, suit != 0
+}
+
+// tileDanger estimates how likely another player is to win off a tile,
+// relative to other tiles.
+func tileDanger(view RoundView, seat int, tile Tile) float64 {
+	for _, discarded := range view.Rivers[seat] {
+		if discarded.Tile == tile {
+			// a player rarely waits on a tile they threw away
+			return 0.05
+		}
+	}
+	i, ok := kindIndex[tile]
+	if !ok {
+		// jokers and anything else which is not part of a meld
+		return 0
+	}
+	var danger float64
+	if i >= 27 {
+		// honours only complete a pair or a pong, so they are safer when
+		// more of them have been seen
+		danger = 0.2 * float64(view.Unseen[tile])
+		if tile.Suit() == SuitDragons && dragonPongs(view.Hands[seat].Revealed) > 0 {
+			danger *= 1.5
+		}
+		return danger
+	}
+	switch rank := i%9 + 1; {
+	case rank == 1 || rank == 9:
+		danger = 0.5
+	case rank == 2 || rank == 8:
+		danger = 0.7
+	default:
+		danger = 1
+	}
+	// a wait on both sides of a sequence is unlikely when the tile three
+	// away was thrown (筋)
+	for _, discarded := range view.Rivers[seat] {
+		j, ok := kindIndex[discarded.Tile]
+		if ok && j < 27 && j/9 == i/9 && (j-i == 3 || i-j == 3) {
+			danger *= 0.5
+		}
+	}
+	if suit, ok := flushSuit(view.Hands[seat].Revealed); ok {
+		if tile.Suit() == suit {
+			danger *= 2
+		} else {
+			danger *= 0.3
+		}
+	}
+	return danger
+}
+
+// dragonPongs counts the pongs and gangs of dragons among revealed melds.
+func dragonPongs(revealed Melds) int {
+	n := 0
+	for _, meld := range revealed {
+		if (meld.Type == MeldPong || meld.Type == MeldGang) && meld.Tiles[0].Suit() == SuitDragons {
+			n++
+		}
+	}
+	return n
+}
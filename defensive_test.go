@@ -0,0 +1,96 @@
+package mahjong
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldDefend(t *testing.T) {
+	assert.False(t, shouldDefend(0, [4]float64{0, 5, 5, 5}))
+	assert.False(t, shouldDefend(1, [4]float64{0, 1, 0, 0}))
+	assert.True(t, shouldDefend(1, [4]float64{0, 2, 0, 0}))
+	assert.False(t, shouldDefend(3, [4]float64{0, 0.5, 0.5, 0.5}))
+	assert.True(t, shouldDefend(3, [4]float64{0, 0, 1, 0}))
+}
+
+func Test_tileDanger(t *testing.T) {
+	view := RoundView{
+		Rivers: [4][]DiscardedTile{1: {{Tile: TileBamboo2}, {Tile: TileDots2}, {Tile: TileDots8}}},
+		Unseen: map[Tile]int{TileWindsEast: 3, TileWindsWest: 1},
+	}
+	assert.Equal(t, 0.05, tileDanger(view, 1, TileBamboo2), "own discard")
+	assert.Equal(t, 1.0, tileDanger(view, 1, TileBamboo4), "middle tile")
+	assert.Equal(t, 0.5, tileDanger(view, 1, TileBamboo5), "three away from a discard")
+	assert.Equal(t, 0.25, tileDanger(view, 1, TileDots5), "three away from a discard on both sides")
+	assert.InDelta(t, 0.6, tileDanger(view, 1, TileWindsEast), 1e-9, "honour with three unseen")
+	assert.InDelta(t, 0.2, tileDanger(view, 1, TileWindsWest), 1e-9, "honour with one unseen")
+	assert.Equal(t, 0.5, tileDanger(view, 2, TileCharacters9), "terminal")
+	t.Run("flush signals", func(t *testing.T) {
+		view := RoundView{Hands: [4]Hand{3: {Revealed: Melds{
+			{Type: MeldChi, Tiles: []Tile{TileBamboo1, TileBamboo2, TileBamboo3}},
+			{Type: MeldPong, Tiles: []Tile{TileWindsNorth, TileWindsNorth, TileWindsNorth}},
+		}}}}
+		assert.Equal(t, 2.0, tileDanger(view, 3, TileBamboo5))
+		assert.InDelta(t, 0.3, tileDanger(view, 3, TileDots5), 1e-9)
+	})
+}
+
+func Test_opponentThreats(t *testing.T) {
+	revealed := Melds{
+		{Type: MeldPong, Tiles: []Tile{TileDragonsRed, TileDragonsRed, TileDragonsRed}},
+		{Type: MeldChi, Tiles: []Tile{TileBamboo1, TileBamboo2, TileBamboo3}},
+	}
+	view := RoundView{
+		Seat:      0,
+		DrawsLeft: 50,
+		Hands:     [4]Hand{{Revealed: revealed}, {Revealed: revealed}, {Revealed: revealed, Dead: true}},
+	}
+	threats := opponentThreats(view)
+	assert.Zero(t, threats[0], "no threat to yourself")
+	assert.InDelta(t, 0.4*2.5, threats[1], 1e-9)
+	assert.Zero(t, threats[2], "dead hands cannot win")
+	assert.Zero(t, threats[3])
+}
+
+func TestDefensiveBot_Decide(t *testing.T) {
+	// a hand far from winning
+	concealed := []Tile{
+		TileDots1, TileDots4, TileDots7, TileBamboo1, TileBamboo4, TileBamboo7, TileCharacters1, TileCharacters5,
+		TileCharacters9, TileWindsEast, TileWindsSouth, TileDragonsWhite, TileDragonsWhite, TileBamboo5,
+	}
+	threatening := Hand{Revealed: Melds{
+		{Type: MeldPong, Tiles: []Tile{TileDragonsRed, TileDragonsRed, TileDragonsRed}},
+		{Type: MeldPong, Tiles: []Tile{TileDragonsGreen, TileDragonsGreen, TileDragonsGreen}},
+		{Type: MeldChi, Tiles: []Tile{TileBamboo1, TileBamboo2, TileBamboo3}},
+	}}
+	view := RoundView{
+		Seat:      0,
+		DrawsLeft: 10,
+		Hands:     [4]Hand{{Concealed: NewTileBag(concealed)}, threatening},
+		Rivers:    [4][]DiscardedTile{1: {{Tile: TileCharacters5}}},
+		Unseen:    map[Tile]int{TileWindsEast: 3, TileWindsSouth: 3, TileDragonsWhite: 1},
+	}
+	var actions []Action
+	for _, tile := range sortedTiles(view.Hands[0].Concealed) {
+		actions = append(actions, Action{Type: ActionDiscard, Tiles: []Tile{tile}})
+	}
+	t.Run("discards a safe tile when defending", func(t *testing.T) {
+		assert.Equal(t, &Action{Type: ActionDiscard, Tiles: []Tile{TileCharacters5}}, DefensiveBot{}.Decide(view, actions))
+	})
+	t.Run("plays like GreedyBot when nobody is threatening", func(t *testing.T) {
+		view := view
+		view.Hands = [4]Hand{{Concealed: NewTileBag(concealed)}}
+		view.DrawsLeft = 50
+		assert.Equal(t, GreedyBot{}.Decide(view, actions), DefensiveBot{}.Decide(view, actions))
+	})
+	t.Run("does not claim when defending", func(t *testing.T) {
+		view := view
+		view.Turn = 1
+		view.Discards = []Tile{TileDragonsWhite}
+		assert.Nil(t, DefensiveBot{}.Decide(view, []Action{{Type: ActionPong}}))
+	})
+	t.Run("always wins", func(t *testing.T) {
+		assert.Equal(t, &Action{Type: ActionHu}, DefensiveBot{}.Decide(view, []Action{{Type: ActionHu}}))
+	})
+}
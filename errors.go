@@ -0,0 +1,54 @@
+package mahjong
+
+import (
+	"errors"
+)
+
+// Reasons an action may not be allowed.
+var (
+	ErrRoundFinished    = errors.New("round finished")
+	ErrWrongTurn        = errors.New("wrong turn")
+	ErrWrongPhase       = errors.New("wrong phase")
+	ErrReservedDuration = errors.New("cannot act during reserved duration")
+	ErrMissingTiles     = errors.New("missing tiles")
+	ErrNoDiscards       = errors.New("no discards")
+	ErrInvalidSequence  = errors.New("invalid sequence")
+	ErrNoDrawsLeft      = errors.New("no draws left")
+	ErrDrawsRemaining   = errors.New("some draws remaining")
+	ErrNoTai            = errors.New("no tai")
+	ErrAlreadyWon       = errors.New("already won")
+	ErrTooLate          = errors.New("too late")
+	ErrNoPrecedence     = errors.New("no precedence")
+	ErrNothingToUndo    = errors.New("nothing to undo")
+	ErrUndoDraw         = errors.New("cannot undo drawing tiles")
+	ErrDeadHand         = errors.New("dead hand")
+	ErrSacredDiscard    = errors.New("sacred discard")
+	ErrUnknownAction    = errors.New("unknown action")
+)
+
+// ActionError is returned when a player is not allowed to take an action.
+// Its Err is one of the reasons above.
+type ActionError struct {
+	Seat   int
+	Action ActionType
+	Err    error
+}
+
+func (e *ActionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ActionError) Unwrap() error {
+	return e.Err
+}
+
+func newActionError(seat int, action ActionType, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ActionError{
+		Seat:   seat,
+		Action: action,
+		Err:    err,
+	}
+}
@@ -0,0 +1,21 @@
+package mahjong
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionError(t *testing.T) {
+	r := &Round{Turn: 0}
+	err := r.Discard(1, time.Now(), TileDragonsRed)
+	var actionErr *ActionError
+	if assert.True(t, errors.As(err, &actionErr)) {
+		assert.Equal(t, 1, actionErr.Seat)
+		assert.Equal(t, ActionDiscard, actionErr.Action)
+	}
+	assert.True(t, errors.Is(err, ErrWrongTurn))
+	assert.EqualError(t, err, "wrong turn")
+}
@@ -8,21 +8,86 @@ func timeInMillis(t time.Time) int64 {
 	return t.UnixNano() / 1e6
 }
 
+func timeFromMillis(ms int64) time.Time {
+	return time.Unix(ms/1000, (ms%1000)*1e6)
+}
+
 // EventType represents the type of an event.
 type EventType string
 
 // Possible event types.
 const (
-	EventStart   = "start"
-	EventDraw    = "draw"
-	EventDiscard = "discard"
-	EventChi     = "chi"
-	EventPong    = "pong"
-	EventGang    = "gang"
-	EventHu      = "hu"
-	EventEnd     = "end"
-	EventFlower  = "flower"
-	EventBitten  = "bitten"
+	// EventStart starts a round. Its tiles are the shuffled wall before it is
+	// broken.
+	EventStart EventType = "start"
+
+	// EventDice rolls the dice to decide where to break the wall.
+	EventDice EventType = "dice"
+
+	// EventDeal deals tiles from the front of the wall to a player.
+	EventDeal EventType = "deal"
+
+	// EventDraw draws a tile from the front of the wall.
+	EventDraw EventType = "draw"
+
+	// EventReplace draws a replacement tile from the back of the wall after
+	// a flower or a gang.
+	EventReplace EventType = "replace"
+
+	EventDiscard EventType = "discard"
+	EventChi     EventType = "chi"
+	EventPong    EventType = "pong"
+	EventGang    EventType = "gang"
+
+	// EventHu declares a win. Its tiles are the winner's finished hand.
+	EventHu EventType = "hu"
+
+	// EventFalseWin declares a win without a winning hand. Its tiles are the
+	// player's concealed tiles when their hand is exposed as a result.
+	EventFalseWin EventType = "false_win"
+
+	EventEnd EventType = "end"
+
+	// EventAbort ends a round in an abortive draw. Its tiles are the tiles
+	// which caused it, if any.
+	EventAbort EventType = "abort"
+
+	EventFlower EventType = "flower"
+	EventBitten EventType = "bitten"
+
+	// EventPayout changes each player's score.
+	EventPayout EventType = "payout"
+
+	// EventResult ends a round with a result.
+	EventResult EventType = "result"
+
+	// EventUndo reverses the most recent action and every event which
+	// followed it.
+	EventUndo EventType = "undo"
+
+	// EventStack moves a tile in the wall to the front for testing. Its tile
+	// is the tile moved.
+	EventStack EventType = "stack"
+
+	// EventSwap exchanges tiles in a player's concealed hand with tiles in
+	// the wall for testing. Its tiles are the player's new concealed tiles.
+	EventSwap EventType = "swap"
+)
+
+// Visibility determines which players may see the tiles involved in an event.
+type Visibility int
+
+// Possible visibilities.
+const (
+	// VisibilityPublic events are seen in full by everyone.
+	VisibilityPublic Visibility = iota
+
+	// VisibilityOwner events only show their tiles to the player they pertain
+	// to.
+	VisibilityOwner
+
+	// VisibilityHidden events never show their tiles to anyone.
+	VisibilityHidden
 )
 
 // Event represents a player's view of an event.
@@ -38,6 +103,25 @@ type Event struct {
 
 	// Tiles are the tiles involved in an event.
 	Tiles []Tile `json:"tiles"`
+
+	// Visibility determines who may see the tiles involved in an event.
+	Visibility Visibility `json:"visibility"`
+
+	// Wind is the prevailing wind for a start event.
+	Wind Direction `json:"wind,omitempty"`
+
+	// Streak is the dealer's streak for a start event.
+	Streak int `json:"streak,omitempty"`
+
+	// Dice is the result of each die for a dice event.
+	Dice []int `json:"dice,omitempty"`
+
+	// Scores contains the scores at the start of a round for a start event,
+	// and the change in each player's score for a payout event.
+	Scores *[4]int `json:"scores,omitempty"`
+
+	// Result is the outcome of a round for a result event.
+	Result *Result `json:"result,omitempty"`
 }
 
 func newEvent(eventType EventType, seat int, t time.Time, tiles ...Tile) Event {
@@ -48,3 +132,240 @@ func newEvent(eventType EventType, seat int, t time.Time, tiles ...Tile) Event {
 		Tiles: tiles,
 	}
 }
+
+// newPrivateEvent returns an event whose tiles are only visible to the player
+// it pertains to.
+func newPrivateEvent(eventType EventType, seat int, t time.Time, tiles ...Tile) Event {
+	e := newEvent(eventType, seat, t, tiles...)
+	e.Visibility = VisibilityOwner
+	return e
+}
+
+func newPayoutEvent(seat int, t time.Time, deltas [4]int) Event {
+	return Event{
+		Type:   EventPayout,
+		Seat:   seat,
+		Time:   timeInMillis(t),
+		Scores: &deltas,
+	}
+}
+
+// visibleTo returns the view of an event from a certain seat. Tiles which
+// the seat may not see are removed.
+func (e Event) visibleTo(seat int) Event {
+	switch e.Visibility {
+	case VisibilityHidden:
+		e.Tiles = nil
+	case VisibilityOwner:
+		if e.Seat != seat {
+			e.Tiles = nil
+		}
+	}
+	return e
+}
+
+// apply updates the state of a round according to an event.
+func (r *Round) apply(e Event) {
+	if e.isAction() {
+		r.lastAction = &e
+		r.lastActionDrew = false
+	}
+	if r.lastAction != nil && (e.Type == EventDraw || e.Type == EventReplace) {
+		r.lastActionDrew = true
+	}
+	switch e.Type {
+	case EventStart:
+		r.Dealer = e.Seat
+		r.Wind = e.Wind
+		r.Streak = e.Streak
+		if e.Scores != nil {
+			r.Scores = *e.Scores
+		}
+		r.splitWall(e.Tiles, r.Rules.Tiles.deadWallSize())
+		r.Dice = nil
+		r.Hands = newHands()
+		r.Discards = []Tile{}
+		r.Turn = r.Dealer
+		r.Phase = PhaseDiscard
+		r.Result = nil
+		r.Finished = false
+		r.WinningTile = ""
+		r.Passed = [4][]Tile{}
+		r.Rivers = [4][]DiscardedTile{}
+		r.drawn = ""
+	case EventDice:
+		r.Dice = e.Dice
+		r.breakWall(e.Dice)
+	case EventDeal:
+		r.Wall = r.Wall[len(e.Tiles):]
+		r.Hands[e.Seat].Concealed.Add(e.Tiles...)
+	case EventDraw:
+		r.passDiscard(e.Seat)
+		r.Wall = r.Wall[1:]
+		r.Hands[e.Seat].Concealed.Add(e.Tiles...)
+		r.drawn = e.Tiles[0]
+		r.Phase = PhaseDiscard
+	case EventReplace:
+		r.drawReplacement()
+		r.Hands[e.Seat].Concealed.Add(e.Tiles...)
+		// flowers replaced while dealing are not drawn on anyone's turn
+		if e.Seat == r.Turn && r.lastAction != nil {
+			r.drawn = e.Tiles[0]
+		}
+	case EventFlower:
+		r.Hands[e.Seat].Concealed.Remove(e.Tiles...)
+		r.Hands[e.Seat].Flowers = append(r.Hands[e.Seat].Flowers, e.Tiles...)
+	case EventPayout:
+		for i, delta := range e.Scores {
+			r.Scores[i] += delta
+		}
+	case EventDiscard:
+		r.Hands[e.Seat].Concealed.Remove(e.Tiles...)
+		r.Discards = append(r.Discards, e.Tiles...)
+		r.Rivers[e.Seat] = append(r.Rivers[e.Seat], DiscardedTile{
+			Tile:      e.Tiles[0],
+			Tsumogiri: e.Tiles[0] == r.drawn,
+		})
+		r.drawn = ""
+		r.Turn = (e.Seat + 1) % 4
+		r.Phase = PhaseDraw
+	case EventChi:
+		r.passDiscard(e.Seat)
+		feeder := r.previousTurn()
+		tile := r.claimLastDiscard(e.Seat)
+		rest := removeTile(append([]Tile(nil), e.Tiles...), tile)
+		hand := &r.Hands[e.Seat]
+		hand.Concealed.Remove(rest...)
+		hand.Revealed = append(hand.Revealed, Meld{
+			Type:   MeldChi,
+			Tiles:  append([]Tile(nil), e.Tiles...),
+			Feeder: &feeder,
+		})
+		r.Phase = PhaseDiscard
+	case EventPong:
+		r.passDiscard(e.Seat)
+		feeder := r.previousTurn()
+		tile := r.claimLastDiscard(e.Seat)
+		hand := &r.Hands[e.Seat]
+		hand.Concealed.RemoveN(tile, 2)
+		hand.Revealed = append(hand.Revealed, Meld{
+			Type:   MeldPong,
+			Tiles:  []Tile{tile},
+			Feeder: &feeder,
+		})
+		r.Turn = e.Seat
+		r.Phase = PhaseDiscard
+	case EventGang:
+		r.applyGang(e)
+	case EventHu:
+		hand := &r.Hands[e.Seat]
+		if r.Phase == PhaseDraw {
+			if !r.Finished {
+				// take the winning tile from the discard pile
+				r.WinningTile = r.claimLastDiscard(e.Seat)
+			} else {
+				// take it from the previous winner
+				previous := &r.Hands[r.Result.Winner]
+				previous.Finished = removeTile(previous.Finished, r.WinningTile)
+				r.markClaimed(e.Seat)
+			}
+		}
+		hand.Concealed = TileBag{}
+		hand.Finished = append([]Tile(nil), e.Tiles...)
+	case EventFalseWin:
+		switch r.Rules.FalseWin {
+		case FalseWinExposed:
+			r.Hands[e.Seat].Exposed = true
+		case FalseWinDeadHand:
+			r.Hands[e.Seat].Dead = true
+		}
+	case EventResult:
+		r.Result = e.Result
+		r.Finished = true
+	case EventStack:
+		r.applyStack(e)
+	case EventSwap:
+		r.applySwap(e)
+	}
+}
+
+func (r *Round) applyGang(e Event) {
+	tile := e.Tiles[0]
+	hand := &r.Hands[e.Seat]
+	switch {
+	case r.Phase == PhaseDraw:
+		// gang from discard
+		r.passDiscard(e.Seat)
+		feeder := r.previousTurn()
+		r.claimLastDiscard(e.Seat)
+		hand.Concealed.RemoveN(tile, 3)
+		hand.Revealed = append(hand.Revealed, Meld{
+			Type:   MeldGang,
+			Tiles:  []Tile{tile},
+			Feeder: &feeder,
+		})
+		r.Turn = e.Seat
+		r.Phase = PhaseDiscard
+	case hand.Concealed.Count(tile) > 3:
+		// concealed gang
+		hand.Concealed.RemoveN(tile, 4)
+		hand.Revealed = append(hand.Revealed, Meld{
+			Type:  MeldGang,
+			Tiles: []Tile{tile},
+		})
+	default:
+		// promote a pong to a gang
+		for i, meld := range hand.Revealed {
+			if meld.Type == MeldPong && meld.Tiles[0] == tile {
+				hand.Concealed.Remove(tile)
+				hand.Revealed[i].Type = MeldGang
+				return
+			}
+		}
+	}
+}
+
+// isAction reports whether an event is the first event of a player action.
+// Events which follow it up to the next action belong to that action.
+func (e Event) isAction() bool {
+	switch e.Type {
+	case EventDraw, EventDiscard, EventChi, EventPong, EventGang, EventHu, EventFalseWin, EventEnd:
+		return true
+	}
+	return false
+}
+
+// lastAction returns the index of the most recent action in events, or -1 if
+// there is none.
+func lastAction(events []Event) int {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].isAction() {
+			return i
+		}
+	}
+	return -1
+}
+
+// withoutUndone returns the events which remain in effect after undo events
+// have been processed.
+func withoutUndone(events []Event) []Event {
+	var effective []Event
+	for _, e := range events {
+		if e.Type == EventUndo {
+			if i := lastAction(effective); i != -1 {
+				effective = effective[:i]
+			}
+			continue
+		}
+		effective = append(effective, e)
+	}
+	return effective
+}
+
+// emit appends events to the event log of a round and applies them.
+func (r *Round) emit(events ...Event) {
+	for _, e := range events {
+		r.Events = append(r.Events, e)
+		r.apply(e)
+	}
+}
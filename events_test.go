@@ -0,0 +1,39 @@
+package mahjong
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvent_visibleTo(t *testing.T) {
+	tiles := []Tile{TileDots1}
+	tests := []struct {
+		name       string
+		visibility Visibility
+		seat       int
+		want       []Tile
+	}{
+		{"public event from another seat", VisibilityPublic, 2, tiles},
+		{"public event from bystander", VisibilityPublic, -1, tiles},
+		{"owner event from owner", VisibilityOwner, 1, tiles},
+		{"owner event from another seat", VisibilityOwner, 2, nil},
+		{"owner event from bystander", VisibilityOwner, 4, nil},
+		{"hidden event from owner", VisibilityHidden, 1, nil},
+		{"hidden event from bystander", VisibilityHidden, -1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Event{
+				Type:       EventDraw,
+				Seat:       1,
+				Tiles:      tiles,
+				Visibility: tt.visibility,
+			}
+			got := e.visibleTo(tt.seat)
+			assert.Equal(t, tt.want, got.Tiles)
+			assert.Equal(t, e.Type, got.Type)
+			assert.Equal(t, e.Seat, got.Seat)
+		})
+	}
+}
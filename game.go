@@ -1,5 +1,10 @@
 package mahjong
 
+import (
+	"math/rand"
+	"time"
+)
+
 // MeldType represents the type of a melded set.
 type MeldType int
 
@@ -15,6 +20,13 @@ const (
 type Meld struct {
 	Type  MeldType `json:"type"`
 	Tiles []Tile   `json:"tiles"`
+
+	// Jokers are the tiles in a meld which jokers stand in for.
+	Jokers []Tile `json:"jokers,omitempty"`
+
+	// Feeder is the integer offset of the player whose discard was claimed
+	// to reveal a meld, or nil if it was made from a player's own tiles.
+	Feeder *int `json:"feeder,omitempty"`
 }
 
 type Melds []Meld
@@ -24,8 +36,8 @@ func (m Melds) Len() int {
 }
 
 func (m Melds) Less(i, j int) bool {
-	if m[i].Type < m[j].Type {
-		return true
+	if m[i].Type != m[j].Type {
+		return m[i].Type < m[j].Type
 	}
 	return m[i].Tiles[0] < m[j].Tiles[0]
 }
@@ -34,19 +46,26 @@ func (m Melds) Swap(i, j int) {
 	m[i], m[j] = m[j], m[i]
 }
 
+// Tiles returns the tiles making up melds, with jokers in place of the tiles
+// they stand in for.
 func (m Melds) Tiles() []Tile {
 	var tiles []Tile
 	for _, meld := range m {
+		var meldTiles []Tile
 		switch meld.Type {
 		case MeldChi:
-			tiles = append(tiles, meld.Tiles...)
+			meldTiles = append(meldTiles, meld.Tiles...)
 		case MeldPong:
-			tiles = append(tiles, meld.Tiles[0], meld.Tiles[0], meld.Tiles[0])
+			meldTiles = append(meldTiles, meld.Tiles[0], meld.Tiles[0], meld.Tiles[0])
 		case MeldGang:
-			tiles = append(tiles, meld.Tiles[0], meld.Tiles[0], meld.Tiles[0], meld.Tiles[0])
+			meldTiles = append(meldTiles, meld.Tiles[0], meld.Tiles[0], meld.Tiles[0], meld.Tiles[0])
 		case MeldEyes:
-			tiles = append(tiles, meld.Tiles[0], meld.Tiles[0])
+			meldTiles = append(meldTiles, meld.Tiles[0], meld.Tiles[0])
 		}
+		for _, tile := range meld.Jokers {
+			meldTiles = append(removeTile(meldTiles, tile), TileJoker)
+		}
+		tiles = append(tiles, meldTiles...)
 	}
 	return tiles
 }
@@ -114,18 +133,43 @@ type Hand struct {
 	Revealed  Melds   `json:"revealed"`
 	Concealed TileBag `json:"concealed,omitempty"`
 	Finished  []Tile  `json:"finished,omitempty"`
+
+	// Exposed indicates that the concealed tiles of a hand are shown to
+	// everyone after a false win.
+	Exposed bool `json:"exposed,omitempty"`
+
+	// Dead indicates that a hand can no longer win after a false win.
+	Dead bool `json:"dead,omitempty"`
 }
 
 // View returns another player's view of a hand.
 func (h Hand) View() Hand {
+	concealed := TileBag{"": h.Concealed.Cardinality()}
+	if h.Exposed {
+		concealed = h.Concealed
+	}
 	return Hand{
 		Flowers:   h.Flowers,
 		Revealed:  h.Revealed,
-		Concealed: TileBag{"": h.Concealed.Cardinality()},
+		Concealed: concealed,
 		Finished:  h.Finished,
+		Exposed:   h.Exposed,
+		Dead:      h.Dead,
 	}
 }
 
+// DiscardedTile represents a tile in a player's discards.
+type DiscardedTile struct {
+	Tile Tile `json:"tile"`
+
+	// ClaimedBy is the integer offset of the player who claimed a discard
+	// to chi, pong, gang or hu with, or nil if it was not claimed.
+	ClaimedBy *int `json:"claimed_by,omitempty"`
+
+	// Tsumogiri indicates that a discard was the tile just drawn (摸切).
+	Tsumogiri bool `json:"tsumogiri,omitempty"`
+}
+
 // Direction represents a wind direction.
 type Direction int
 
@@ -160,6 +204,10 @@ type Result struct {
 	// Wind is the prevailing wind for the round.
 	Wind Direction `json:"wind"`
 
+	// Streak is the number of consecutive rounds the dealer had kept before
+	// the round.
+	Streak int `json:"streak"`
+
 	// Winner is the integer offset of the winner for the round, or -1 if the round ended in a draw.
 	Winner int `json:"winner"`
 
@@ -171,4 +219,194 @@ type Result struct {
 
 	// WinningTiles is the set of flowers and tiles belonging to the winner.
 	WinningTiles []Tile `json:"winning_tiles"`
+
+	// Tai are the elements of the winning hand which scored points.
+	Tai []Tai `json:"tai,omitempty"`
+
+	// Reason is why the round ended.
+	Reason ResultReason `json:"reason,omitempty"`
+
+	// Liable is the integer offset of the player who pays for everyone
+	// because they fed the winner's big hand (包), or nil if nobody does.
+	Liable *int `json:"liable,omitempty"`
+}
+
+// liable returns the integer offset of the player liable for a result, or -1
+// if nobody is.
+func (r *Result) liable() int {
+	if r.Liable == nil {
+		return -1
+	}
+	return *r.Liable
+}
+
+// ResultReason represents why a round ended.
+type ResultReason string
+
+// Possible result reasons.
+const (
+	// ReasonWin is when a player won.
+	ReasonWin ResultReason = "win"
+
+	// ReasonWallExhausted is when the wall ran out without a winner.
+	ReasonWallExhausted ResultReason = "wall_exhausted"
+
+	// ReasonFourKongs is when four kongs were declared by more than one
+	// player (四杠散了).
+	ReasonFourKongs ResultReason = "four_kongs"
+
+	// ReasonFourWinds is when every player discarded the same wind on the
+	// first go-around (四风连打).
+	ReasonFourWinds ResultReason = "four_winds"
+
+	// ReasonNineTerminals is when a player was dealt nine different
+	// terminals and honours (九种九牌).
+	ReasonNineTerminals ResultReason = "nine_terminals"
+)
+
+// SeedSource provides seeds for shuffling the wall of each round. A
+// *rand.Rand may be used as a SeedSource.
+type SeedSource interface {
+	Int63() int64
+}
+
+// GameLength determines when a game ends. A game always ends after the North
+// round. Zero values do not limit the length of a game.
+type GameLength struct {
+	// Winds is the number of prevailing winds played, starting from East.
+	Winds int `json:"winds,omitempty"`
+
+	// Hands is the maximum number of rounds played.
+	Hands int `json:"hands,omitempty"`
+
+	// Duration is how long a game may go on for. No new rounds are started
+	// after the duration has passed.
+	Duration time.Duration `json:"duration,omitempty"`
+
+	// BustThreshold ends a game once a player has lost at least this many
+	// points.
+	BustThreshold int `json:"bust_threshold,omitempty"`
+}
+
+// over reports whether a game with certain results should end instead of
+// continuing with the next round.
+func (l GameLength) over(next *Round, results []Result, elapsed time.Duration) bool {
+	if l.Winds > 0 && int(next.Wind) >= l.Winds {
+		return true
+	}
+	if l.Hands > 0 && len(results) >= l.Hands {
+		return true
+	}
+	if l.Duration > 0 && elapsed >= l.Duration {
+		return true
+	}
+	if l.BustThreshold > 0 {
+		for _, score := range next.Scores {
+			if score <= -l.BustThreshold {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Game represents a full game of mahjong, which is a sequence of rounds
+// played until there are no more rounds.
+type Game struct {
+	Rules            Rules
+	ReservedDuration time.Duration
+	Length           GameLength
+
+	// StartTime is when the first round of a game was started.
+	StartTime time.Time
+
+	// Round is the current round, or the last round if the game is over.
+	Round *Round
+
+	// Results contains the results of every finished round in order.
+	Results []Result
+
+	// Finished indicates whether a game is over.
+	Finished bool
+
+	// Seeds provides the seed for each round. If Seeds is nil, seeds are
+	// taken from the default source in math/rand.
+	Seeds SeedSource `json:"-"`
+}
+
+// NewGame returns a new game which has not been started.
+func NewGame(rules Rules, reservedDuration time.Duration, seeds SeedSource) *Game {
+	return &Game{
+		Rules:            rules,
+		ReservedDuration: reservedDuration,
+		Results:          []Result{},
+		Seeds:            seeds,
+	}
+}
+
+func (g *Game) seed() int64 {
+	if g.Seeds == nil {
+		return rand.Int63()
+	}
+	return g.Seeds.Int63()
+}
+
+// Start starts the first round of a game, with the player in the first seat
+// as the dealer. It fails if the tile set in the rules is not valid.
+func (g *Game) Start(t time.Time) error {
+	if g.Round != nil {
+		return ErrAlreadyStarted
+	}
+	if err := g.Rules.Tiles.Validate(); err != nil {
+		return err
+	}
+	g.Round = &Round{
+		Dealer:           0,
+		Wind:             DirectionEast,
+		Rules:            g.Rules,
+		ReservedDuration: g.ReservedDuration,
+	}
+	g.Round.Start(g.seed(), t)
+	g.StartTime = t
+	return nil
+}
+
+// NextRound records the result of the current round and starts the next
+// one. If there are no more rounds according to the length of the game, the
+// game is finished and ErrNoMoreRounds is returned.
+func (g *Game) NextRound(t time.Time) error {
+	if g.Round == nil {
+		return ErrNotStarted
+	}
+	if g.Finished {
+		return ErrNoMoreRounds
+	}
+	next, err := g.Round.Next()
+	if err != nil && err != ErrNoMoreRounds {
+		return err
+	}
+	g.Results = append(g.Results, *g.Round.Result)
+	if err == ErrNoMoreRounds || g.Length.over(next, g.Results, t.Sub(g.StartTime)) {
+		g.Finished = true
+		return ErrNoMoreRounds
+	}
+	g.Round = next
+	g.Round.Start(g.seed(), t)
+	return nil
+}
+
+// Scores returns the running scores of each player.
+func (g *Game) Scores() [4]int {
+	if g.Round == nil {
+		return [4]int{}
+	}
+	return g.Round.Scores
+}
+
+// SeatWind returns the seat wind of a player in the current round.
+func (g *Game) SeatWind(seat int) Direction {
+	if g.Round == nil {
+		return Direction(seat)
+	}
+	return g.Round.seatWind(seat)
 }
@@ -0,0 +1,149 @@
+package mahjong
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGame_Start(t *testing.T) {
+	t.Run("starts first round", func(t *testing.T) {
+		g := NewGame(RulesDefault, time.Second, rand.New(rand.NewSource(1)))
+		err := g.Start(time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, g.Round.Dealer)
+		assert.Equal(t, DirectionEast, g.Round.Wind)
+		assert.Equal(t, RulesDefault, g.Round.Rules)
+		assert.Equal(t, time.Second, g.Round.ReservedDuration)
+		assert.Equal(t, DirectionSouth, g.SeatWind(1))
+	})
+	t.Run("walls are determined by seed source", func(t *testing.T) {
+		g1 := NewGame(RulesDefault, 0, rand.New(rand.NewSource(1)))
+		g2 := NewGame(RulesDefault, 0, rand.New(rand.NewSource(1)))
+		_ = g1.Start(time.Now())
+		_ = g2.Start(time.Now())
+		assert.Equal(t, g1.Round.Events[0].Tiles, g2.Round.Events[0].Tiles)
+	})
+	t.Run("cannot start twice", func(t *testing.T) {
+		g := NewGame(RulesDefault, 0, nil)
+		_ = g.Start(time.Now())
+		err := g.Start(time.Now())
+		assert.Equal(t, ErrAlreadyStarted, err)
+	})
+	t.Run("cannot start with too few tiles", func(t *testing.T) {
+		rules := Rules{Limit: 5, Tiles: TileSet{NoHonours: true, Suits: []Suit{SuitDots}}}
+		g := NewGame(rules, 0, nil)
+		err := g.Start(time.Now())
+		assert.Equal(t, ErrTooFewTiles, err)
+		assert.Nil(t, g.Round)
+	})
+}
+
+func TestGame_NextRound(t *testing.T) {
+	t.Run("round must be finished", func(t *testing.T) {
+		g := NewGame(RulesDefault, 0, nil)
+		_ = g.Start(time.Now())
+		err := g.NextRound(time.Now())
+		assert.Equal(t, ErrUnfinished, err)
+	})
+	t.Run("starts next round", func(t *testing.T) {
+		result := Result{Dealer: 0, Wind: DirectionEast, Winner: 2, Loser: -1, Points: 1}
+		g := NewGame(RulesDefault, 0, nil)
+		g.Round = &Round{
+			Scores:   [4]int{-1, -1, 3, -1},
+			Finished: true,
+			Result:   &result,
+		}
+		err := g.NextRound(time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, []Result{result}, g.Results)
+		assert.Equal(t, 1, g.Round.Dealer)
+		assert.Equal(t, [4]int{-1, -1, 3, -1}, g.Scores())
+		assert.Equal(t, DirectionEast, g.SeatWind(1))
+		assert.False(t, g.Finished)
+	})
+	t.Run("finishes game after last round", func(t *testing.T) {
+		result := Result{Dealer: 3, Wind: DirectionNorth, Winner: -1, Loser: -1}
+		g := NewGame(RulesDefault, 0, nil)
+		g.Round = &Round{
+			Dealer:   3,
+			Wind:     DirectionNorth,
+			Finished: true,
+			Result:   &result,
+		}
+		err := g.NextRound(time.Now())
+		assert.Equal(t, ErrNoMoreRounds, err)
+		assert.True(t, g.Finished)
+		assert.Equal(t, []Result{result}, g.Results)
+	})
+}
+
+func TestGame_Length(t *testing.T) {
+	now := time.Now()
+	newGame := func(length GameLength, dealer int, wind Direction, scores [4]int) *Game {
+		g := NewGame(RulesDefault, 0, nil)
+		g.Length = length
+		g.StartTime = now
+		g.Round = &Round{
+			Scores:   scores,
+			Dealer:   dealer,
+			Wind:     wind,
+			Finished: true,
+			Result:   &Result{Dealer: dealer, Wind: wind, Winner: -1, Loser: -1},
+		}
+		return g
+	}
+	t.Run("east only", func(t *testing.T) {
+		g := newGame(GameLength{Winds: 1}, 2, DirectionEast, [4]int{})
+		assert.NoError(t, g.NextRound(now))
+		g = newGame(GameLength{Winds: 1}, 3, DirectionEast, [4]int{})
+		assert.Equal(t, ErrNoMoreRounds, g.NextRound(now))
+	})
+	t.Run("east only continues while dealer wins", func(t *testing.T) {
+		g := newGame(GameLength{Winds: 1}, 3, DirectionEast, [4]int{})
+		g.Round.Result.Winner = 3
+		assert.NoError(t, g.NextRound(now))
+		assert.Equal(t, 3, g.Round.Dealer)
+	})
+	t.Run("hand count", func(t *testing.T) {
+		g := newGame(GameLength{Hands: 2}, 0, DirectionEast, [4]int{})
+		g.Results = []Result{{}}
+		assert.Equal(t, ErrNoMoreRounds, g.NextRound(now))
+		assert.Len(t, g.Results, 2)
+	})
+	t.Run("time limit", func(t *testing.T) {
+		g := newGame(GameLength{Duration: time.Hour}, 0, DirectionEast, [4]int{})
+		assert.NoError(t, g.NextRound(now.Add(59*time.Minute)))
+		g.Round.Finished = true
+		g.Round.Result = &Result{Winner: -1, Loser: -1}
+		assert.Equal(t, ErrNoMoreRounds, g.NextRound(now.Add(time.Hour)))
+	})
+	t.Run("bust threshold", func(t *testing.T) {
+		g := newGame(GameLength{BustThreshold: 10}, 0, DirectionEast, [4]int{-9, 3, 3, 3})
+		assert.NoError(t, g.NextRound(now))
+		g = newGame(GameLength{BustThreshold: 10}, 0, DirectionEast, [4]int{-10, 4, 3, 3})
+		assert.Equal(t, ErrNoMoreRounds, g.NextRound(now))
+		assert.True(t, g.Finished)
+	})
+}
+
+func TestMelds_Less(t *testing.T) {
+	melds := Melds{
+		{Type: MeldPong, Tiles: []Tile{TileDots1}},
+		{Type: MeldChi, Tiles: []Tile{TileDots5, TileDots6, TileDots7}},
+		{Type: MeldEyes, Tiles: []Tile{TileBamboo1}},
+		{Type: MeldChi, Tiles: []Tile{TileDots2, TileDots3, TileDots4}},
+	}
+	assert.True(t, melds.Less(1, 0))
+	assert.False(t, melds.Less(0, 1))
+	sort.Sort(melds)
+	assert.Equal(t, Melds{
+		{Type: MeldChi, Tiles: []Tile{TileDots2, TileDots3, TileDots4}},
+		{Type: MeldChi, Tiles: []Tile{TileDots5, TileDots6, TileDots7}},
+		{Type: MeldPong, Tiles: []Tile{TileDots1}},
+		{Type: MeldEyes, Tiles: []Tile{TileBamboo1}},
+	}, melds)
+}
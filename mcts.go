@@ -0,0 +1,321 @@
+package mahjong
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// defaultMCTSIterations is how many rounds MCTSBot simulates for each
+// decision when its Iterations are not set.
+const defaultMCTSIterations = 100
+
+// mctsExploration weighs trying out options which have been simulated less
+// against those which have done well so far, in points.
+const mctsExploration = 8
+
+// MCTSBot is a Player which searches for the action with the best expected
+// change in its score. For each decision it repeatedly deals the tiles it
+// cannot see into a plausible set of hidden hands and wall, picks one of its
+// options using UCB1 and plays the rest of the round out with every player
+// making quick greedy decisions. Despite its name, only its own options are
+// searched this way: it is flat Monte Carlo search rather than a tree search,
+// and later decisions in a simulated round are never explored.
+type MCTSBot struct {
+	// Rules are the rules of the rounds the bot plays in, which are not part
+	// of a RoundView.
+	Rules Rules
+
+	// Seed determines the hands and walls the bot samples. The bot always
+	// makes the same decision for a view with the same seed.
+	Seed int64
+
+	// Iterations is the number of rounds simulated for each decision, or
+	// defaultMCTSIterations if it is zero.
+	Iterations int
+}
+
+// mctsOption is an option considered by MCTSBot and how well it did in
+// simulations.
+type mctsOption struct {
+	action *Action
+	visits int
+	total  int
+}
+
+func (o *mctsOption) mean() float64 {
+	return float64(o.total) / float64(o.visits)
+}
+
+func (b MCTSBot) Decide(view RoundView, actions []Action) *Action {
+	for _, actionType := range []ActionType{ActionHu, ActionEnd} {
+		if action := findAction(actions, actionType); action != nil {
+			return action
+		}
+	}
+	options := mctsOptions(view, actions)
+	if len(options) == 1 {
+		return options[0].action
+	}
+	iterations := b.Iterations
+	if iterations == 0 {
+		iterations = defaultMCTSIterations
+	}
+	// the nth simulation of every option is played on the same hands and
+	// wall, so that options are compared on equal terms
+	rng := rand.New(rand.NewSource(b.Seed ^ int64(len(view.Events))<<2 ^ int64(view.Seat)))
+	var seeds []int64
+	for i := 0; i < iterations; i++ {
+		option := selectOption(options, i)
+		if option.visits == len(seeds) {
+			seeds = append(seeds, rng.Int63())
+		}
+		sample := rand.New(rand.NewSource(seeds[option.visits]))
+		r := determinise(view, b.Rules, sample)
+		option.total += rollout(r, view.Seat, option.action, sample)
+		option.visits++
+	}
+	best := options[0]
+	for _, option := range options[1:] {
+		if option.visits > 0 && (best.visits == 0 || option.mean() > best.mean()) {
+			best = option
+		}
+	}
+	return best.action
+}
+
+// maxMCTSDiscards is the most discards MCTSBot considers.
+const maxMCTSDiscards = 4
+
+// mctsOptions returns the options worth simulating out of the legal actions
+// available: every claim, passing on claims when allowed and the discards
+// which leave a hand closest to winning, best first.
+func mctsOptions(view RoundView, actions []Action) []*mctsOption {
+	hand := view.Hands[view.Seat]
+	counts := countTiles(hand.Concealed)
+	sets := 4 - len(hand.Revealed)
+	var options []*mctsOption
+	var discards []discardChoice
+	for i, action := range actions {
+		if action.Type != ActionDiscard {
+			options = append(options, &mctsOption{action: &actions[i]})
+			continue
+		}
+		tile := action.Tiles[0]
+		counts.add(tile, -1)
+		discards = append(discards, discardChoice{
+			tile:    tile,
+			shanten: counts.shanten(sets),
+			live:    counts.liveTiles(sets, view.Unseen),
+		})
+		counts.add(tile, 1)
+	}
+	sort.SliceStable(discards, func(i, j int) bool {
+		return discards[i].better(discards[j])
+	})
+	for i, discard := range discards {
+		if i == maxMCTSDiscards || discard.shanten > discards[0].shanten {
+			break
+		}
+		options = append(options, &mctsOption{action: &Action{Type: ActionDiscard, Tiles: []Tile{discard.tile}}})
+	}
+	if !MustAct(actions) {
+		options = append(options, &mctsOption{})
+	}
+	return options
+}
+
+// selectOption returns the option to simulate next after a number of
+// simulations: each option once, then the one with the best upper confidence
+// bound.
+func selectOption(options []*mctsOption, simulations int) *mctsOption {
+	var best *mctsOption
+	bound := math.Inf(-1)
+	for _, option := range options {
+		if option.visits == 0 {
+			return option
+		}
+		b := option.mean() + mctsExploration*math.Sqrt(math.Log(float64(simulations))/float64(option.visits))
+		if b > bound {
+			best, bound = option, b
+		}
+	}
+	return best
+}
+
+// determinise returns a round consistent with what a player can see, with
+// the tiles they cannot see dealt at random into other players' concealed
+// hands, the wall and the dead wall. Unseen flowers only go into the walls.
+func determinise(view RoundView, rules Rules, rng *rand.Rand) *Round {
+	r := &Round{
+		Scores:           view.Scores,
+		Discards:         append([]Tile(nil), view.Discards...),
+		Wind:             view.Wind,
+		Dealer:           view.Dealer,
+		Streak:           view.Streak,
+		Turn:             view.Turn,
+		Phase:            view.Phase,
+		Rules:            rules,
+		LastActionTime:   time.Unix(0, view.LastActionTime*int64(time.Millisecond)),
+		ReservedDuration: time.Duration(view.ReservedDuration) * time.Millisecond,
+	}
+	r.Passed[view.Seat] = append([]Tile(nil), view.SacredDiscards...)
+	for seat, river := range view.Rivers {
+		r.Rivers[seat] = append([]DiscardedTile(nil), river...)
+	}
+	var hidden []Tile
+	for _, tile := range append(tileKinds[:], TileJoker) {
+		for i := 0; i < view.Unseen[tile]; i++ {
+			hidden = append(hidden, tile)
+		}
+	}
+	flowers := NewTileBag(nil)
+	for _, tile := range rules.Tiles.Tiles() {
+		if rules.Tiles.isFlower(tile) {
+			flowers.Add(tile)
+		}
+	}
+	for _, hand := range view.Hands {
+		flowers.Remove(hand.Flowers...)
+	}
+	shuffle := func(tiles []Tile) {
+		rng.Shuffle(len(tiles), func(i, j int) {
+			tiles[i], tiles[j] = tiles[j], tiles[i]
+		})
+	}
+	shuffle(hidden)
+	for seat, hand := range view.Hands {
+		revealed := make(Melds, len(hand.Revealed))
+		for i, meld := range hand.Revealed {
+			revealed[i] = meld
+			revealed[i].Tiles = append([]Tile(nil), meld.Tiles...)
+		}
+		concealed := NewTileBag(nil)
+		if seat == view.Seat || hand.Exposed {
+			for tile, n := range hand.Concealed {
+				concealed[tile] = n
+			}
+		} else {
+			n := hand.Concealed.Cardinality()
+			if n > len(hidden) {
+				n = len(hidden)
+			}
+			concealed.Add(hidden[:n]...)
+			hidden = hidden[n:]
+		}
+		r.Hands[seat] = Hand{
+			Flowers:   append([]Tile(nil), hand.Flowers...),
+			Revealed:  revealed,
+			Concealed: concealed,
+			Finished:  hand.Finished,
+			Exposed:   hand.Exposed,
+			Dead:      hand.Dead,
+		}
+	}
+	// flowers are replaced as soon as they are drawn, so unseen flowers can
+	// only be in the walls
+	hidden = append(hidden, sortedBag(flowers)...)
+	shuffle(hidden)
+	live := view.DrawsLeft
+	if live > len(hidden) {
+		live = len(hidden)
+	}
+	r.Wall = hidden[:live]
+	r.DeadWall = hidden[live:]
+	return r
+}
+
+// sortedBag returns the tiles in a bag in order.
+func sortedBag(bag TileBag) []Tile {
+	var tiles []Tile
+	for _, tile := range sortedTiles(bag) {
+		for i := 0; i < bag[tile]; i++ {
+			tiles = append(tiles, tile)
+		}
+	}
+	return tiles
+}
+
+// rollout plays out a round from the point of view of a player who first
+// takes a certain action, or passes if it is nil, and returns how much their
+// score changed by.
+func rollout(r *Round, seat int, action *Action, rng *rand.Rand) int {
+	before := r.Scores[seat]
+	first := true
+	decide := func(s int, actions []Action) *Action {
+		if first && s == seat {
+			return action
+		}
+		return rolloutDecide(r, s, actions, rng)
+	}
+	for steps := 0; !r.Finished && steps < maxSteps; steps++ {
+		err := playStep(r, decide)
+		first = false
+		if err != nil {
+			break
+		}
+	}
+	return r.Scores[seat] - before
+}
+
+// rolloutDecide makes a quick decision for a player in a simulated round. It
+// wins and gangs whenever it can, pongs when that brings its hand closer to
+// winning and discards a tile which leaves its hand as close to winning as
+// possible, chosen at random among equally good ones.
+func rolloutDecide(r *Round, seat int, actions []Action, rng *rand.Rand) *Action {
+	for _, actionType := range []ActionType{ActionHu, ActionGang, ActionEnd} {
+		if action := findAction(actions, actionType); action != nil {
+			return action
+		}
+	}
+	hand := r.Hands[seat]
+	counts := countTiles(hand.Concealed)
+	sets := 4 - len(hand.Revealed)
+	if action := findAction(actions, ActionPong); action != nil {
+		after := counts
+		after.add(r.lastDiscard(), -2)
+		if closestDiscard(after, sets-1, handTiles(after)) < counts.shanten(sets) {
+			return action
+		}
+	}
+	if action := findAction(actions, ActionDraw); action != nil {
+		return action
+	}
+	var candidates []Tile
+	for _, action := range actions {
+		if action.Type == ActionDiscard {
+			candidates = append(candidates, action.Tiles[0])
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	best, n := Tile(""), 0
+	shanten := closestDiscard(counts, sets, candidates)
+	for _, tile := range candidates {
+		counts.add(tile, -1)
+		if counts.shanten(sets) == shanten {
+			n++
+			if rng.Intn(n) == 0 {
+				best = tile
+			}
+		}
+		counts.add(tile, 1)
+	}
+	return &Action{Type: ActionDiscard, Tiles: []Tile{best}}
+}
+
+// closestDiscard returns how close to winning a hand can be after
+// discarding one of certain tiles.
+func closestDiscard(hand tileCounts, sets int, candidates []Tile) int {
+	best := math.MaxInt32
+	for _, tile := range candidates {
+		hand.add(tile, -1)
+		if shanten := hand.shanten(sets); shanten < best {
+			best = shanten
+		}
+		hand.add(tile, 1)
+	}
+	return best
+}
@@ -0,0 +1,83 @@
+package mahjong
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startedRound returns a round played for some steps by greedy players.
+func startedRound(t *testing.T, seed int64, steps int) *Round {
+	r := &Round{Rules: RulesDefault, ReservedDuration: time.Second}
+	r.Start(seed, time.Unix(0, 0))
+	decide := func(seat int, actions []Action) *Action {
+		return GreedyBot{}.Decide(r.View(seat), actions)
+	}
+	for i := 0; i < steps && !r.Finished; i++ {
+		require.NoError(t, playStep(r, decide))
+	}
+	return r
+}
+
+func Test_determinise(t *testing.T) {
+	r := startedRound(t, 1, 30)
+	seat := r.Turn
+	view := r.View(seat)
+	d := determinise(view, r.Rules, rand.New(rand.NewSource(1)))
+	require.NoError(t, d.Validate())
+	assert.Equal(t, r.Hands[seat].Concealed, d.Hands[seat].Concealed)
+	for i := range r.Hands {
+		assert.Equal(t, r.Hands[i].Revealed, d.Hands[i].Revealed)
+		assert.ElementsMatch(t, r.Hands[i].Flowers, d.Hands[i].Flowers)
+		assert.Equal(t, r.Hands[i].Concealed.Cardinality(), d.Hands[i].Concealed.Cardinality())
+	}
+	for seed := int64(0); seed < 50; seed++ {
+		d := determinise(view, r.Rules, rand.New(rand.NewSource(seed)))
+		for i, hand := range d.Hands {
+			for tile := range hand.Concealed {
+				assert.False(t, r.Rules.Tiles.isFlower(tile), "seed %d: flower %s in concealed hand of seat %d", seed, tile, i)
+			}
+		}
+	}
+	assert.Len(t, d.Wall, len(r.Wall))
+	assert.Len(t, d.DeadWall, len(r.DeadWall))
+	assert.Equal(t, r.Discards, d.Discards)
+	assert.Equal(t, r.Turn, d.Turn)
+	assert.Equal(t, r.Phase, d.Phase)
+	now := r.LastActionTime.Add(r.ReservedDuration)
+	assert.Equal(t, r.LegalActions(seat, now), d.LegalActions(seat, now))
+}
+
+func TestMCTSBot_Decide(t *testing.T) {
+	r := startedRound(t, 2, 22)
+	require.Equal(t, PhaseDiscard, r.Phase)
+	seat := r.Turn
+	view := r.View(seat)
+	var actions []Action
+	for _, action := range view.Actions {
+		if action.Type != ActionUndo {
+			actions = append(actions, action)
+		}
+	}
+	bot := MCTSBot{Rules: r.Rules, Seed: 1, Iterations: 20}
+	t.Run("takes a legal action", func(t *testing.T) {
+		assert.Contains(t, actions, *bot.Decide(view, actions))
+	})
+	t.Run("reproducible for a seed", func(t *testing.T) {
+		first := bot.Decide(view, actions)
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, first, bot.Decide(view, actions))
+		}
+	})
+	t.Run("does not change the round", func(t *testing.T) {
+		before := r.View(seat)
+		bot.Decide(view, actions)
+		assert.Equal(t, before, r.View(seat))
+	})
+	t.Run("always wins", func(t *testing.T) {
+		assert.Equal(t, &Action{Type: ActionHu}, bot.Decide(view, []Action{{Type: ActionDraw}, {Type: ActionHu}}))
+	})
+}
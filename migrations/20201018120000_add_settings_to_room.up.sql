@@ -0,0 +1,2 @@
+alter table rooms
+    add column settings jsonb not null default '{"undo": "off"}';
@@ -0,0 +1,2 @@
+alter table rooms
+    add column started_at timestamptz not null default now();
@@ -1,7 +1,11 @@
 package parlour
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	mathRand "math/rand"
+	"sort"
 	"time"
 
 	"github.com/yi-jiayu/mahjong.go"
@@ -11,40 +15,173 @@ type AI interface {
 	Think(view RoomView) *Action
 }
 
-type discardRandomTileAI struct{}
+var errUnknownAI = errors.New("unknown ai")
 
-func (ai discardRandomTileAI) Think(view RoomView) *Action {
-	if view.Round == nil {
-		return nil
+// DefaultAI is the AI bots are added with unless another is chosen.
+const DefaultAI = "medium"
+
+// ais are the AIs bots can be added with by name: difficulties from easy to
+// hard, and personalities which play in a certain style. Each returns a new
+// AI for a game played with certain rules.
+var ais = map[string]func(rules mahjong.Rules) AI{
+	"easy": func(rules mahjong.Rules) AI {
+		return NewPlayerAI(sloppyPlayer{mahjong.GreedyBot{}})
+	},
+	"medium": func(rules mahjong.Rules) AI {
+		return NewPlayerAI(mahjong.GreedyBot{})
+	},
+	"hard": func(rules mahjong.Rules) AI {
+		// the seed is logged so that the bot's decisions can be reproduced
+		seed := mathRand.Int63()
+		fmt.Printf("ai=hard seed=%d\n", seed)
+		return NewPlayerAI(mahjong.MCTSBot{Rules: rules, Seed: seed})
+	},
+	"cautious": func(rules mahjong.Rules) AI {
+		return NewPlayerAI(mahjong.DefensiveBot{})
+	},
+	"magpie": func(rules mahjong.Rules) AI {
+		return NewPlayerAI(claimingPlayer{mahjong.GreedyBot{}})
+	},
+}
+
+// RegisterAI adds an AI bots can be added with, replacing any with the same
+// name. It should be called before serving requests.
+func RegisterAI(name string, newAI func(rules mahjong.Rules) AI) {
+	ais[name] = newAI
+}
+
+// AINames returns the names of the AIs bots can be added with.
+func AINames() []string {
+	names := make([]string, 0, len(ais))
+	for name := range ais {
+		names = append(names, name)
 	}
-	if view.Round.Turn != view.Round.Seat {
-		return nil
+	sort.Strings(names)
+	return names
+}
+
+// NewAI returns a new AI by name for a game played with certain rules.
+func NewAI(name string, rules mahjong.Rules) (AI, error) {
+	newAI, ok := ais[name]
+	if !ok {
+		return nil, errUnknownAI
 	}
-	if view.Round.Phase == mahjong.PhaseDraw {
-		time.Sleep(time.Duration(view.Round.ReservedDuration)*time.Millisecond + time.Second)
-		return &Action{
-			Nonce: view.Nonce,
-			Type:  ActionDraw,
-		}
-	} else if view.Round.Phase == mahjong.PhaseDiscard {
-		if view.Round.DrawsLeft <= 0 && !view.Round.Finished {
-			return &Action{
-				Nonce: view.Nonce,
-				Type:  ActionEndRound,
+	return newAI(rules), nil
+}
+
+// findAction returns the first of certain actions of one of some types, or
+// nil if there is none.
+func findAction(actions []mahjong.Action, types ...mahjong.ActionType) *mahjong.Action {
+	for i, action := range actions {
+		for _, actionType := range types {
+			if action.Type == actionType {
+				return &actions[i]
 			}
 		}
-		var tileToDiscard mahjong.Tile
-		for tile := range view.Round.Hands[view.Round.Seat].Concealed {
-			tileToDiscard = tile
-			break
+	}
+	return nil
+}
+
+// sloppyPlayer plays like another player, except that it never claims a
+// discard unless it wins with it and throws away a random tile a third of the
+// time.
+type sloppyPlayer struct {
+	mahjong.Player
+}
+
+func (p sloppyPlayer) Decide(view mahjong.RoundView, actions []mahjong.Action) *mahjong.Action {
+	if hu := findAction(actions, mahjong.ActionHu); hu != nil {
+		return hu
+	}
+	var discards []mahjong.Action
+	for _, action := range actions {
+		if action.Type == mahjong.ActionDiscard {
+			discards = append(discards, action)
 		}
-		return &Action{
-			Nonce: view.Nonce,
-			Type:  ActionDiscard,
-			Tiles: []mahjong.Tile{tileToDiscard},
+	}
+	if len(discards) > 0 && mathRand.Intn(3) == 0 {
+		return &discards[mathRand.Intn(len(discards))]
+	}
+	decision := p.Player.Decide(view, actions)
+	if decision == nil {
+		return nil
+	}
+	switch {
+	case decision.Type == mahjong.ActionChi,
+		decision.Type == mahjong.ActionPong,
+		decision.Type == mahjong.ActionGang && len(decision.Tiles) == 0:
+		return findAction(actions, mahjong.ActionDraw)
+	}
+	return decision
+}
+
+// claimingPlayer plays like another player, except that it claims every
+// discard it can.
+type claimingPlayer struct {
+	mahjong.Player
+}
+
+func (p claimingPlayer) Decide(view mahjong.RoundView, actions []mahjong.Action) *mahjong.Action {
+	if claim := findAction(actions, mahjong.ActionHu, mahjong.ActionGang, mahjong.ActionPong, mahjong.ActionChi); claim != nil {
+		return claim
+	}
+	return p.Player.Decide(view, actions)
+}
+
+// botDelay is how long bots wait after the reserved duration is over before
+// acting, so that people can follow along.
+const botDelay = time.Second
+
+// playerAI lets a player from the mahjong package think for a bot.
+type playerAI struct {
+	Player mahjong.Player
+}
+
+// NewPlayerAI returns an AI which decides what to do with a player from the
+// mahjong package, such as mahjong.GreedyBot or mahjong.DefensiveBot.
+func NewPlayerAI(player mahjong.Player) AI {
+	return playerAI{player}
+}
+
+func (ai playerAI) Think(view RoomView) *Action {
+	actions := legalActions(view)
+	if len(actions) == 0 {
+		return nil
+	}
+	decision := ai.Player.Decide(*view.Round, actions)
+	if decision == nil {
+		return nil
+	}
+	waitToAct(view.Round)
+	return &Action{
+		Nonce: view.Nonce,
+		Type:  ActionType(decision.Type),
+		Tiles: decision.Tiles,
+	}
+}
+
+// legalActions returns the actions a bot can take in a round apart from
+// undo, if there is a round in progress.
+func legalActions(view RoomView) []mahjong.Action {
+	round := view.Round
+	if round == nil || round.Finished {
+		return nil
+	}
+	var actions []mahjong.Action
+	for _, action := range round.Actions {
+		if action.Type != mahjong.ActionUndo {
+			actions = append(actions, action)
 		}
 	}
-	return nil
+	return actions
+}
+
+// waitToAct waits until the reserved duration after the last action in a
+// round is over, and then botDelay.
+func waitToAct(round *mahjong.RoundView) {
+	lastActionTime := time.Unix(0, round.LastActionTime*int64(time.Millisecond))
+	reservedDuration := time.Duration(round.ReservedDuration) * time.Millisecond
+	time.Sleep(time.Until(lastActionTime.Add(reservedDuration + botDelay)))
 }
 
 type Bot struct {
@@ -56,6 +193,10 @@ type Bot struct {
 
 func (b *Bot) Start(roomService *roomService) {
 	for view := range b.Updates {
+		if view.Phase == PhaseFinished {
+			b.stop()
+			return
+		}
 		go func(view RoomView) {
 			action := b.AI.Think(view)
 			if action == nil {
@@ -68,3 +209,26 @@ func (b *Bot) Start(roomService *roomService) {
 		}(view)
 	}
 }
+
+// stop unsubscribes a bot from its room and closes its AI if it can be
+// closed. Updates are drained until the bot is unsubscribed so that the room
+// is never left waiting to send one.
+func (b *Bot) stop() {
+	removed := make(chan struct{})
+	go func() {
+		b.Room.RemoveClient(b.Updates)
+		close(removed)
+	}()
+	for {
+		select {
+		case <-b.Updates:
+		case <-removed:
+			if closer, ok := b.AI.(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					fmt.Printf("room=%s bot=%s error closing ai: %v\n", b.Room.ID, b.ID, err)
+				}
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package parlour
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yi-jiayu/mahjong.go"
+)
+
+func Test_playerAI_Think(t *testing.T) {
+	t.Run("does nothing before the game starts", func(t *testing.T) {
+		ai := playerAI{mahjong.GreedyBot{}}
+		assert.Nil(t, ai.Think(RoomView{}))
+	})
+	t.Run("does nothing without any actions", func(t *testing.T) {
+		ai := playerAI{mahjong.GreedyBot{}}
+		view := RoomView{Round: &mahjong.RoundView{Actions: []mahjong.Action{{Type: mahjong.ActionUndo}}}}
+		assert.Nil(t, ai.Think(view))
+	})
+	t.Run("takes the player's decision", func(t *testing.T) {
+		ai := playerAI{mahjong.GreedyBot{}}
+		view := RoomView{
+			Nonce: 3,
+			Round: &mahjong.RoundView{
+				Seat:    1,
+				Turn:    1,
+				Phase:   mahjong.PhaseDraw,
+				Actions: []mahjong.Action{{Type: mahjong.ActionDraw}},
+			},
+		}
+		assert.Equal(t, &Action{Nonce: 3, Type: ActionDraw}, ai.Think(view))
+	})
+}
+
+func TestNewAI(t *testing.T) {
+	for _, name := range AINames() {
+		t.Run(name, func(t *testing.T) {
+			ai, err := NewAI(name, mahjong.RulesDefault)
+			assert.NoError(t, err)
+			assert.NotNil(t, ai)
+		})
+	}
+	t.Run("unknown", func(t *testing.T) {
+		_, err := NewAI("grandmaster", mahjong.RulesDefault)
+		assert.Equal(t, errUnknownAI, err)
+	})
+}
+
+func Test_sloppyPlayer_Decide(t *testing.T) {
+	p := sloppyPlayer{mahjong.GreedyBot{}}
+	view := mahjong.RoundView{
+		Seat: 1,
+		Hands: [4]mahjong.Hand{1: {Concealed: mahjong.NewTileBag([]mahjong.Tile{
+			mahjong.TileDragonsRed, mahjong.TileDragonsRed, mahjong.TileBamboo1, mahjong.TileBamboo5, mahjong.TileDots9,
+		})}},
+		Discards: []mahjong.Tile{mahjong.TileDragonsRed},
+	}
+	t.Run("never claims", func(t *testing.T) {
+		assert.Nil(t, p.Decide(view, []mahjong.Action{{Type: mahjong.ActionPong}}))
+		draw := []mahjong.Action{{Type: mahjong.ActionDraw}, {Type: mahjong.ActionPong}}
+		assert.Equal(t, &draw[0], p.Decide(view, draw))
+	})
+	t.Run("always wins", func(t *testing.T) {
+		actions := []mahjong.Action{{Type: mahjong.ActionPong}, {Type: mahjong.ActionHu}}
+		assert.Equal(t, &actions[1], p.Decide(view, actions))
+	})
+}
+
+func Test_claimingPlayer_Decide(t *testing.T) {
+	p := claimingPlayer{mahjong.GreedyBot{}}
+	actions := []mahjong.Action{
+		{Type: mahjong.ActionDraw},
+		{Type: mahjong.ActionChi, Tiles: []mahjong.Tile{mahjong.TileBamboo1, mahjong.TileBamboo2}},
+	}
+	assert.Equal(t, &actions[1], p.Decide(mahjong.RoundView{}, actions))
+}
+
+// closingAI is an AI which never acts and records being closed.
+type closingAI struct {
+	closed chan struct{}
+}
+
+func (ai closingAI) Think(view RoomView) *Action {
+	return nil
+}
+
+func (ai closingAI) Close() error {
+	close(ai.closed)
+	return nil
+}
+
+func TestBot_Start(t *testing.T) {
+	t.Run("stops once the game is finished", func(t *testing.T) {
+		room := NewRoom(Player{ID: "alice"})
+		ai := closingAI{closed: make(chan struct{})}
+		bot := Bot{ID: "bot", Room: room, Updates: make(chan RoomView), AI: ai}
+		room.clients[bot.Updates] = bot.ID
+		stopped := make(chan struct{})
+		go func() {
+			bot.Start(nil)
+			close(stopped)
+		}()
+		room.WithLock(func(r *Room) {
+			r.Phase = PhaseFinished
+			r.broadcast()
+			// a bot still reading updates does not block another broadcast
+			r.broadcast()
+		})
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("bot did not stop")
+		}
+		<-ai.closed
+		assert.NotContains(t, room.clients, bot.Updates)
+	})
+}
@@ -0,0 +1,84 @@
+package parlour
+
+import (
+	"errors"
+
+	"github.com/yi-jiayu/mahjong.go"
+)
+
+// ErrorResponse is the body of an unsuccessful response. Its code is stable
+// and may be used by clients to show a localised message.
+type ErrorResponse struct {
+	Code    string             `json:"code"`
+	Message string             `json:"message"`
+	Seat    *int               `json:"seat,omitempty"`
+	Action  mahjong.ActionType `json:"action,omitempty"`
+}
+
+// Error codes.
+const (
+	CodeBadRequest    = "bad_request"
+	CodeInternalError = "internal_error"
+)
+
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{errRoomFull, "room_full"},
+	{errNotInRoom, "not_in_room"},
+	{errForbidden, "forbidden"},
+	{errInvalidNonce, "invalid_nonce"},
+	{errNameTaken, "name_taken"},
+	{errInvalidAction, "invalid_action"},
+	{errMissingTiles, "missing_action_tiles"},
+	{errUndoDisabled, "undo_disabled"},
+	{errNoUndoRequest, "no_undo_request"},
+	{errUnknownAI, "unknown_ai"},
+	{errNotEnoughPlayers, "not_enough_players"},
+	{errRoundNotStarted, "round_not_started"},
+	{mahjong.ErrRoundFinished, "round_finished"},
+	{mahjong.ErrWrongTurn, "wrong_turn"},
+	{mahjong.ErrWrongPhase, "wrong_phase"},
+	{mahjong.ErrReservedDuration, "reserved_duration"},
+	{mahjong.ErrMissingTiles, "missing_tiles"},
+	{mahjong.ErrNoDiscards, "no_discards"},
+	{mahjong.ErrInvalidSequence, "invalid_sequence"},
+	{mahjong.ErrNoDrawsLeft, "no_draws_left"},
+	{mahjong.ErrDrawsRemaining, "draws_remaining"},
+	{mahjong.ErrNoTai, "no_tai"},
+	{mahjong.ErrAlreadyWon, "already_won"},
+	{mahjong.ErrTooLate, "too_late"},
+	{mahjong.ErrNoPrecedence, "no_precedence"},
+	{mahjong.ErrNothingToUndo, "nothing_to_undo"},
+	{mahjong.ErrUndoDraw, "undo_draw"},
+	{mahjong.ErrDeadHand, "dead_hand"},
+	{mahjong.ErrSacredDiscard, "sacred_discard"},
+	{mahjong.ErrUnknownAction, "unknown_action"},
+	{mahjong.ErrNoMoreRounds, "no_more_rounds"},
+	{mahjong.ErrUnfinished, "round_unfinished"},
+	{mahjong.ErrAlreadyStarted, "already_started"},
+	{mahjong.ErrNotStarted, "not_started"},
+	{mahjong.ErrTooFewTiles, "too_few_tiles"},
+}
+
+// newErrorResponse returns the response for a non-internal error.
+func newErrorResponse(err error) ErrorResponse {
+	resp := ErrorResponse{
+		Code:    CodeBadRequest,
+		Message: err.Error(),
+	}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			resp.Code = c.code
+			break
+		}
+	}
+	var actionErr *mahjong.ActionError
+	if errors.As(err, &actionErr) {
+		seat := actionErr.Seat
+		resp.Seat = &seat
+		resp.Action = actionErr.Action
+	}
+	return resp
+}
@@ -0,0 +1,55 @@
+package parlour
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yi-jiayu/mahjong.go"
+)
+
+func Test_newErrorResponse(t *testing.T) {
+	t.Run("action error", func(t *testing.T) {
+		r := &mahjong.Round{Turn: 0}
+		err := r.Draw(2, time.Now())
+		seat := 2
+		assert.Equal(t, ErrorResponse{
+			Code:    "wrong_turn",
+			Message: "wrong turn",
+			Seat:    &seat,
+			Action:  mahjong.ActionDraw,
+		}, newErrorResponse(&Error{error: err}))
+	})
+	t.Run("unknown action", func(t *testing.T) {
+		err := (&mahjong.Round{}).Act(1, time.Now(), mahjong.Action{Type: "skip"})
+		seat := 1
+		assert.Equal(t, ErrorResponse{
+			Code:    "unknown_action",
+			Message: "unknown action",
+			Seat:    &seat,
+			Action:  "skip",
+		}, newErrorResponse(err))
+	})
+	t.Run("parlour error", func(t *testing.T) {
+		err := fmt.Errorf("tiles is required: %w", errMissingTiles)
+		assert.Equal(t, ErrorResponse{
+			Code:    "missing_action_tiles",
+			Message: "tiles is required: missing action tiles",
+		}, newErrorResponse(err))
+	})
+	t.Run("game error", func(t *testing.T) {
+		err := mahjong.NewGame(mahjong.RulesDefault, 0, nil).NextRound(time.Now())
+		assert.Equal(t, ErrorResponse{
+			Code:    "not_started",
+			Message: "not started",
+		}, newErrorResponse(err))
+	})
+	t.Run("unknown error", func(t *testing.T) {
+		assert.Equal(t, ErrorResponse{
+			Code:    CodeBadRequest,
+			Message: "name is required",
+		}, newErrorResponse(errors.New("name is required")))
+	})
+}
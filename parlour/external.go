@@ -0,0 +1,257 @@
+package parlour
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/yi-jiayu/mahjong.go"
+)
+
+// DefaultExternalTimeout is how long an external AI has to respond to a
+// request by default.
+const DefaultExternalTimeout = 5 * time.Second
+
+// maxExternalLineSize is the longest line an external AI may respond with.
+const maxExternalLineSize = 1 << 20
+
+// ExternalRequest is written to an external AI as a single line of JSON
+// whenever its bot can act. Actions are the legal actions in the round.
+type ExternalRequest struct {
+	ID      int              `json:"id"`
+	View    RoomView         `json:"view"`
+	Actions []mahjong.Action `json:"actions"`
+}
+
+// ExternalResponse is read from an external AI as a single line of JSON in
+// response to the request with the same ID. An action of null passes.
+type ExternalResponse struct {
+	ID     int             `json:"id"`
+	Action *mahjong.Action `json:"action"`
+}
+
+// externalAI is an AI which asks a subprocess what to do over its stdin and
+// stdout. It uses a fallback AI when the subprocess exits, takes too long to
+// respond or responds with an illegal action, and after it is closed.
+type externalAI struct {
+	name     string
+	fallback AI
+	timeout  time.Duration
+
+	// done is closed once the subprocess is stopped.
+	done     chan struct{}
+	doneOnce sync.Once
+
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	responses chan ExternalResponse
+	nextID    int
+	exited    bool
+}
+
+// NewExternalAI starts a command as an external AI, which uses a fallback AI
+// whenever it cannot decide what to do within a timeout.
+func NewExternalAI(fallback AI, timeout time.Duration, name string, args ...string) (AI, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	ai := &externalAI{
+		name:      name,
+		fallback:  fallback,
+		timeout:   timeout,
+		cmd:       cmd,
+		stdin:     stdin,
+		done:      make(chan struct{}),
+		responses: make(chan ExternalResponse),
+	}
+	go ai.read(stdout)
+	return ai, nil
+}
+
+// ExternalAI returns a function for RegisterAI which starts a new process
+// running a command for each bot. Bots fall back to the default AI if the
+// command cannot be started or does not decide in time. Rooms replace the
+// fallback with the default AI they were configured with.
+func ExternalAI(timeout time.Duration, name string, args ...string) func(rules mahjong.Rules) AI {
+	return func(rules mahjong.Rules) AI {
+		fallback, _ := NewAI(DefaultAI, rules)
+		ai, err := NewExternalAI(fallback, timeout, name, args...)
+		if err != nil {
+			fmt.Printf("external_ai=%s error starting: %v\n", name, err)
+			stopped := &externalAI{
+				name:     name,
+				fallback: fallback,
+				done:     make(chan struct{}),
+				exited:   true,
+			}
+			stopped.doneOnce.Do(func() { close(stopped.done) })
+			return stopped
+		}
+		return ai
+	}
+}
+
+// read sends responses from an external AI until its stdout is closed or it
+// is stopped.
+func (ai *externalAI) read(stdout io.Reader) {
+	defer func() {
+		close(ai.responses)
+		_ = ai.cmd.Wait()
+	}()
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(nil, maxExternalLineSize)
+	for scanner.Scan() {
+		var response ExternalResponse
+		if err := json.Unmarshal(scanner.Bytes(), &response); err != nil {
+			fmt.Printf("external_ai=%s error decoding response: %v\n", ai.name, err)
+			continue
+		}
+		select {
+		case ai.responses <- response:
+		case <-ai.done:
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Printf("external_ai=%s error reading: %v\n", ai.name, err)
+	}
+}
+
+func (ai *externalAI) Think(view RoomView) *Action {
+	actions := legalActions(view)
+	if len(actions) == 0 {
+		return nil
+	}
+	decision, ok := ai.ask(view, actions)
+	if !ok {
+		return ai.fallback.Think(view)
+	}
+	if decision == nil {
+		return nil
+	}
+	waitToAct(view.Round)
+	return &Action{
+		Nonce: view.Nonce,
+		Type:  ActionType(decision.Type),
+		Tiles: decision.Tiles,
+	}
+}
+
+// ask asks an external AI which of some actions to take, returning false if
+// it did not decide on a legal one in time.
+func (ai *externalAI) ask(view RoomView, actions []mahjong.Action) (*mahjong.Action, bool) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	if ai.exited {
+		return nil, false
+	}
+	ai.nextID++
+	request := ExternalRequest{
+		ID:      ai.nextID,
+		View:    view,
+		Actions: actions,
+	}
+	line, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("external_ai=%s error encoding request: %v\n", ai.name, err)
+		return nil, false
+	}
+	if _, err := ai.stdin.Write(append(line, '\n')); err != nil {
+		ai.exit(err)
+		return nil, false
+	}
+	timeout := time.After(ai.timeout)
+	for {
+		select {
+		case response, ok := <-ai.responses:
+			if !ok {
+				ai.exit(io.EOF)
+				return nil, false
+			}
+			if response.ID != request.ID {
+				// a late response to an earlier request
+				continue
+			}
+			if response.Action == nil {
+				return nil, true
+			}
+			if !containsAction(actions, *response.Action) {
+				fmt.Printf("external_ai=%s illegal action: %s\n", ai.name, response.Action.Type)
+				return nil, false
+			}
+			return response.Action, true
+		case <-timeout:
+			fmt.Printf("external_ai=%s timed out\n", ai.name)
+			return nil, false
+		case <-ai.done:
+			return nil, false
+		}
+	}
+}
+
+// Close stops an external AI's subprocess. Its fallback is used from then on.
+func (ai *externalAI) Close() error {
+	ai.doneOnce.Do(func() { close(ai.done) })
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.stop()
+	return nil
+}
+
+// exit stops an external AI which can no longer be asked what to do so that
+// its fallback is used from now on.
+func (ai *externalAI) exit(err error) {
+	fmt.Printf("external_ai=%s exited: %v\n", ai.name, err)
+	ai.stop()
+}
+
+// stop kills an external AI's subprocess if it has not been already. ai.mu
+// must be held.
+func (ai *externalAI) stop() {
+	ai.doneOnce.Do(func() { close(ai.done) })
+	if ai.exited {
+		return
+	}
+	ai.exited = true
+	_ = ai.stdin.Close()
+	_ = ai.cmd.Process.Kill()
+}
+
+// containsAction reports whether an action is one of some actions.
+func containsAction(actions []mahjong.Action, action mahjong.Action) bool {
+	for _, a := range actions {
+		if a.Type == action.Type && sameTiles(a.Tiles, action.Tiles) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameTiles reports whether two lists of tiles are the same.
+func sameTiles(a, b []mahjong.Tile) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package parlour
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yi-jiayu/mahjong.go"
+)
+
+// TestExternalAIHelperProcess is run as an external AI by the tests below,
+// behaving according to EXTERNAL_AI_HELPER.
+func TestExternalAIHelperProcess(t *testing.T) {
+	mode := os.Getenv("EXTERNAL_AI_HELPER")
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(nil, maxExternalLineSize)
+	for scanner.Scan() {
+		var request ExternalRequest
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			os.Exit(2)
+		}
+		response := ExternalResponse{ID: request.ID}
+		switch mode {
+		case "last":
+			response.Action = &request.Actions[len(request.Actions)-1]
+		case "pass":
+		case "crash":
+			os.Exit(1)
+		case "slow":
+			time.Sleep(time.Second)
+			response.Action = &request.Actions[0]
+		case "illegal":
+			response.Action = &mahjong.Action{Type: mahjong.ActionHu}
+		}
+		line, _ := json.Marshal(response)
+		fmt.Println(string(line))
+	}
+}
+
+// fixedAI is an AI which always takes the same action.
+type fixedAI struct {
+	action *Action
+}
+
+func (ai fixedAI) Think(view RoomView) *Action {
+	return ai.action
+}
+
+func newHelperAI(t *testing.T, mode string, fallback AI, timeout time.Duration) AI {
+	require.NoError(t, os.Setenv("EXTERNAL_AI_HELPER", mode))
+	defer os.Unsetenv("EXTERNAL_AI_HELPER")
+	ai, err := NewExternalAI(fallback, timeout, os.Args[0], "-test.run=TestExternalAIHelperProcess")
+	require.NoError(t, err)
+	return ai
+}
+
+func Test_externalAI_Think(t *testing.T) {
+	view := RoomView{
+		Nonce: 5,
+		Round: &mahjong.RoundView{
+			Seat:  2,
+			Turn:  1,
+			Phase: mahjong.PhaseDiscard,
+			Actions: []mahjong.Action{
+				{Type: mahjong.ActionUndo},
+				{Type: mahjong.ActionDraw},
+				{Type: mahjong.ActionChi, Tiles: []mahjong.Tile{mahjong.TileDots1, mahjong.TileDots2}},
+			},
+		},
+	}
+	fallback := fixedAI{&Action{Nonce: 5, Type: ActionDraw}}
+	t.Run("does nothing without any actions", func(t *testing.T) {
+		ai := newHelperAI(t, "last", fallback, time.Second)
+		assert.Nil(t, ai.Think(RoomView{}))
+	})
+	t.Run("takes the external decision", func(t *testing.T) {
+		ai := newHelperAI(t, "last", fallback, time.Second)
+		expected := &Action{Nonce: 5, Type: ActionChi, Tiles: []mahjong.Tile{mahjong.TileDots1, mahjong.TileDots2}}
+		assert.Equal(t, expected, ai.Think(view))
+		assert.Equal(t, expected, ai.Think(view))
+	})
+	t.Run("passes", func(t *testing.T) {
+		ai := newHelperAI(t, "pass", fallback, time.Second)
+		assert.Nil(t, ai.Think(view))
+	})
+	t.Run("falls back after crashing", func(t *testing.T) {
+		ai := newHelperAI(t, "crash", fallback, time.Second)
+		assert.Equal(t, fallback.action, ai.Think(view))
+		assert.Equal(t, fallback.action, ai.Think(view))
+	})
+	t.Run("falls back after timing out", func(t *testing.T) {
+		ai := newHelperAI(t, "slow", fallback, 100*time.Millisecond)
+		assert.Equal(t, fallback.action, ai.Think(view))
+	})
+	t.Run("falls back after an illegal action", func(t *testing.T) {
+		ai := newHelperAI(t, "illegal", fallback, time.Second)
+		assert.Equal(t, fallback.action, ai.Think(view))
+	})
+}
+
+func Test_externalAI_Close(t *testing.T) {
+	view := RoomView{
+		Round: &mahjong.RoundView{
+			Actions: []mahjong.Action{{Type: mahjong.ActionDraw}},
+		},
+	}
+	fallback := fixedAI{&Action{Type: ActionDraw}}
+	// read stops once the subprocess is stopped, closing responses
+	stopped := func(t *testing.T, ai *externalAI) {
+		select {
+		case <-ai.responses:
+		case <-time.After(time.Second):
+			t.Fatal("still reading responses")
+		}
+	}
+	t.Run("stops the subprocess", func(t *testing.T) {
+		ai := newHelperAI(t, "last", fallback, time.Second).(*externalAI)
+		assert.NoError(t, ai.Close())
+		stopped(t, ai)
+		assert.Equal(t, fallback.action, ai.Think(view))
+	})
+	t.Run("stops reading a late response", func(t *testing.T) {
+		ai := newHelperAI(t, "slow", fallback, 100*time.Millisecond).(*externalAI)
+		assert.Equal(t, fallback.action, ai.Think(view))
+		// wait for the late response to be waiting to be sent
+		time.Sleep(time.Second)
+		assert.NoError(t, ai.Close())
+		stopped(t, ai)
+	})
+	t.Run("stops after crashing", func(t *testing.T) {
+		ai := newHelperAI(t, "crash", fallback, time.Second).(*externalAI)
+		assert.Equal(t, fallback.action, ai.Think(view))
+		stopped(t, ai)
+		assert.NoError(t, ai.Close())
+	})
+}
+
+func TestRegisterAI(t *testing.T) {
+	defer delete(ais, "helper")
+	require.NoError(t, os.Setenv("EXTERNAL_AI_HELPER", "last"))
+	defer os.Unsetenv("EXTERNAL_AI_HELPER")
+	RegisterAI("helper", ExternalAI(time.Second, os.Args[0], "-test.run=TestExternalAIHelperProcess"))
+	assert.Contains(t, AINames(), "helper")
+	ai, err := NewAI("helper", mahjong.RulesDefault)
+	assert.NoError(t, err)
+	assert.IsType(t, &externalAI{}, ai)
+}
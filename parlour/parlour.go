@@ -9,6 +9,14 @@ type Parlour struct {
 	RoomRepository RoomRepository
 	SessionStore   sessions.Store
 
+	// ValidateRounds checks the state of a room's round after every action
+	// and logs any inconsistencies found.
+	ValidateRounds bool
+
+	// DefaultAI is the name of the AI bots are added with when none is
+	// chosen, or empty to use the one named by the DefaultAI constant.
+	DefaultAI string
+
 	roomService *roomService
 }
 
@@ -2,7 +2,7 @@ package parlour
 
 import (
 	"errors"
-	"math/rand"
+	"fmt"
 	"sync"
 	"time"
 
@@ -18,26 +18,63 @@ const (
 )
 
 var (
-	errRoomFull     = errors.New("room full")
-	errNotInRoom    = errors.New("not in room")
-	errForbidden    = errors.New("forbidden")
-	errInvalidNonce = errors.New("invalid nonce")
+	errRoomFull         = errors.New("room full")
+	errNotInRoom        = errors.New("not in room")
+	errForbidden        = errors.New("forbidden")
+	errInvalidNonce     = errors.New("invalid nonce")
+	errNameTaken        = errors.New("name already taken")
+	errInvalidAction    = errors.New("invalid action")
+	errMissingTiles     = errors.New("missing action tiles")
+	errUndoDisabled     = errors.New("undo disabled")
+	errNoUndoRequest    = errors.New("no undo request")
+	errNotEnoughPlayers = errors.New("not enough players")
+	errRoundNotStarted  = errors.New("round not started")
 )
 
 type Player struct {
 	ID    string `json:"id"`
 	Name  string `json:"name"`
 	IsBot bool   `json:"is_bot"`
+
+	// AI is the name of the AI a bot thinks with.
+	AI string `json:"ai,omitempty"`
+}
+
+// UndoPolicy determines whether players may undo their actions.
+type UndoPolicy string
+
+const (
+	// UndoOff does not allow undoing actions.
+	UndoOff UndoPolicy = "off"
+
+	// UndoUnanimous allows a player to undo an action once every other
+	// player has agreed to it. Bots always agree.
+	UndoUnanimous UndoPolicy = "unanimous"
+)
+
+// Settings are chosen when a room is created.
+type Settings struct {
+	Undo   UndoPolicy         `json:"undo"`
+	Length mahjong.GameLength `json:"length"`
+}
+
+// UndoRequest is a pending request by a player to undo their last action.
+type UndoRequest struct {
+	Seat      int       `json:"seat"`
+	Time      time.Time `json:"time"`
+	Approvals [4]bool   `json:"approvals"`
 }
 
 type Room struct {
-	ID      string
-	Nonce   int
-	Phase   Phase
-	Players []Player
-	Round   *mahjong.Round
-	Scores  [4]int
-	Results []mahjong.Result
+	ID       string
+	Nonce    int
+	Phase    Phase
+	Players  []Player
+	Game     *mahjong.Game
+	Settings Settings
+
+	// UndoRequest is the pending undo request, if any.
+	UndoRequest *UndoRequest
 
 	sync.RWMutex
 
@@ -46,14 +83,16 @@ type Room struct {
 }
 
 type RoomView struct {
-	ID      string             `json:"id"`
-	Nonce   int                `json:"nonce"`
-	Phase   Phase              `json:"phase"`
-	Players []Player           `json:"players"`
-	Round   *mahjong.RoundView `json:"round,omitempty"`
-	Scores  [4]int             `json:"scores"`
-	Results []mahjong.Result   `json:"results"`
-	Inside  bool               `json:"inside"`
+	ID          string             `json:"id"`
+	Nonce       int                `json:"nonce"`
+	Phase       Phase              `json:"phase"`
+	Players     []Player           `json:"players"`
+	Round       *mahjong.RoundView `json:"round,omitempty"`
+	Scores      [4]int             `json:"scores"`
+	Results     []mahjong.Result   `json:"results"`
+	Inside      bool               `json:"inside"`
+	Settings    Settings           `json:"settings"`
+	UndoRequest *UndoRequest       `json:"undo_request,omitempty"`
 }
 
 func (r *Room) WithLock(f func(r *Room)) {
@@ -84,27 +123,46 @@ func (r *Room) view(playerID string) RoomView {
 		Nonce:   r.Nonce,
 		Phase:   r.Phase,
 		Players: r.Players,
-		Results: r.Results,
+		Scores:  r.Game.Scores(),
+		Results: r.Game.Results,
 		Inside:  r.seat(playerID) != -1,
+
+		Settings:    r.Settings,
+		UndoRequest: r.UndoRequest,
 	}
 	if r.Phase == PhaseInProgress {
-		roundView := r.Round.View(r.seat(playerID))
+		roundView := r.Game.Round.View(r.seat(playerID))
+		if r.Settings.Undo != UndoUnanimous {
+			roundView.Actions = withoutUndo(roundView.Actions)
+		}
 		view.Round = &roundView
 	}
 	return view
 }
 
+// withoutUndo returns some actions except undoing, for rooms which do not
+// allow it.
+func withoutUndo(actions []mahjong.Action) []mahjong.Action {
+	allowed := make([]mahjong.Action, 0, len(actions))
+	for _, action := range actions {
+		if action.Type != mahjong.ActionUndo {
+			allowed = append(allowed, action)
+		}
+	}
+	return allowed
+}
+
 func (r *Room) addPlayer(player Player) error {
 	for _, p := range r.Players {
 		if p.Name == player.Name {
 			if p.ID == player.ID {
 				return nil
 			}
-			return errors.New("name already taken")
+			return errNameTaken
 		}
 	}
 	if len(r.Players) == 4 {
-		return errors.New("room full")
+		return errRoomFull
 	}
 	r.Players = append(r.Players, player)
 	r.broadcast()
@@ -122,6 +180,14 @@ const (
 	ActionGang      ActionType = "gang"
 	ActionHu        ActionType = "hu"
 	ActionEndRound  ActionType = "end"
+
+	// ActionUndo requests to undo the player's last action.
+	ActionUndo ActionType = "undo"
+
+	// ActionApproveUndo and ActionRejectUndo respond to a pending undo
+	// request.
+	ActionApproveUndo ActionType = "approve_undo"
+	ActionRejectUndo  ActionType = "reject_undo"
 )
 
 type Action struct {
@@ -132,37 +198,81 @@ type Action struct {
 
 func (r *Room) reduceRound(seat int, t time.Time, action Action) error {
 	if r.Phase != PhaseInProgress {
-		return errors.New("invalid action")
+		return errInvalidAction
 	}
 	switch action.Type {
 	case ActionDraw:
-		return r.Round.Draw(seat, t)
+		return r.Game.Round.Draw(seat, t)
 	case ActionDiscard:
 		if len(action.Tiles) < 1 {
-			return errors.New("tiles is required")
+			return fmt.Errorf("tiles is required: %w", errMissingTiles)
 		}
-		return r.Round.Discard(seat, t, action.Tiles[0])
+		return r.Game.Round.Discard(seat, t, action.Tiles[0])
 	case ActionChi:
 		if len(action.Tiles) < 2 {
-			return errors.New("tiles is too short")
+			return fmt.Errorf("tiles is too short: %w", errMissingTiles)
 		}
-		return r.Round.Chi(seat, t, action.Tiles[0], action.Tiles[1])
+		return r.Game.Round.Chi(seat, t, action.Tiles[0], action.Tiles[1])
 	case ActionPong:
-		return r.Round.Pong(seat, t)
+		return r.Game.Round.Pong(seat, t)
 	case ActionGang:
 		if len(action.Tiles) > 0 {
-			return r.Round.GangFromHand(seat, t, action.Tiles[0])
+			return r.Game.Round.GangFromHand(seat, t, action.Tiles[0])
 		}
-		return r.Round.GangFromDiscard(seat, t)
+		return r.Game.Round.GangFromDiscard(seat, t)
 	case ActionHu:
-		return r.Round.Hu(seat, t)
+		return r.Game.Round.Hu(seat, t)
 	case ActionEndRound:
-		return r.Round.End(seat, t)
+		return r.Game.Round.End(seat, t)
 	default:
-		return errors.New("action is invalid")
+		return errInvalidAction
 	}
 }
 
+func (r *Room) reduceUndo(seat int, t time.Time, action Action) error {
+	if r.Settings.Undo != UndoUnanimous {
+		return errUndoDisabled
+	}
+	if r.Phase != PhaseInProgress {
+		return errInvalidAction
+	}
+	switch action.Type {
+	case ActionUndo:
+		if err := r.Game.Round.CanUndo(seat, t); err != nil {
+			return err
+		}
+		request := &UndoRequest{
+			Seat: seat,
+			Time: t,
+		}
+		for i, player := range r.Players {
+			request.Approvals[i] = i == seat || player.IsBot
+		}
+		r.UndoRequest = request
+	case ActionApproveUndo:
+		if r.UndoRequest == nil {
+			return errNoUndoRequest
+		}
+		r.UndoRequest.Approvals[seat] = true
+	case ActionRejectUndo:
+		if r.UndoRequest == nil {
+			return errNoUndoRequest
+		}
+		r.UndoRequest = nil
+		return nil
+	}
+	for _, approved := range r.UndoRequest.Approvals {
+		if !approved {
+			return nil
+		}
+	}
+	request := r.UndoRequest
+	r.UndoRequest = nil
+	// the request is checked against the time it was made, since any
+	// other action in the meantime would have cancelled it
+	return r.Game.Round.Undo(request.Seat, request.Time)
+}
+
 func (r *Room) reduce(playerID string, action Action) error {
 	seat := r.seat(playerID)
 	if seat == -1 {
@@ -173,14 +283,21 @@ func (r *Room) reduce(playerID string, action Action) error {
 	}
 	t := time.Now()
 	var err error
-	if action.Type == ActionNextRound {
-		err = r.nextRound()
-	} else {
+	switch action.Type {
+	case ActionNextRound:
+		err = r.nextRound(t)
+	case ActionUndo, ActionApproveUndo, ActionRejectUndo:
+		err = r.reduceUndo(seat, t, action)
+	default:
 		err = r.reduceRound(seat, t, action)
 	}
 	if err != nil {
 		return err
 	}
+	if action.Type != ActionUndo && action.Type != ActionApproveUndo {
+		// any other action cancels a pending undo request
+		r.UndoRequest = nil
+	}
 	r.Nonce++
 	r.broadcast()
 	return nil
@@ -220,42 +337,38 @@ func (r *Room) removePlayer(playerID string) {
 	}
 }
 
-func (r *Room) nextRound() error {
+func (r *Room) nextRound(t time.Time) error {
 	if r.Phase == PhaseLobby {
 		if len(r.Players) < 4 {
-			return errors.New("not enough players")
+			return errNotEnoughPlayers
 		}
-		r.Phase = PhaseInProgress
-		r.Round = &mahjong.Round{
-			Rules:            mahjong.RulesDefault,
-			ReservedDuration: 2 * time.Second,
+		if err := r.Game.Start(t); err != nil {
+			return err
 		}
-		r.Round.Start(rand.Int63(), time.Now())
+		r.Phase = PhaseInProgress
 		return nil
 	}
-	next, err := r.Round.Next()
+	err := r.Game.NextRound(t)
 	if err == mahjong.ErrNoMoreRounds {
-		r.Scores = r.Round.Scores
-		r.Results = append(r.Results, *r.Round.Result)
 		r.Phase = PhaseFinished
-		r.Round = nil
 		return nil
 	}
-	if err != nil {
-		return err
-	}
-	r.Results = append(r.Results, *r.Round.Result)
-	r.Round = next
-	r.Round.Start(rand.Int63(), time.Now())
-	return nil
+	return err
+}
+
+// newGame returns the game played in a room with certain settings.
+func newGame(settings Settings) *mahjong.Game {
+	game := mahjong.NewGame(mahjong.RulesDefault, 2*time.Second, nil)
+	game.Length = settings.Length
+	return game
 }
 
 func NewRoom(host Player) *Room {
 	room := &Room{
 		Phase:   PhaseLobby,
 		Players: []Player{host},
+		Game:    newGame(Settings{}),
 		clients: make(map[chan RoomView]string),
-		Results: []mahjong.Result{},
 	}
 	return room
 }
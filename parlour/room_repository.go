@@ -85,14 +85,16 @@ func (p *PostgresRoomRepository) Save(room *Room) error {
 			if err != nil {
 				return fmt.Errorf("error inserting room: %w", err)
 			}
-			_, err = tx.Exec(ctx, `insert into rooms (id, nonce, phase, players, round, results)
-values ($1, $2, $3, $4, $5, $6)`,
+			_, err = tx.Exec(ctx, `insert into rooms (id, nonce, phase, players, round, results, settings, started_at)
+values ($1, $2, $3, $4, $5, $6, $7, $8)`,
 				id,
 				room.Nonce,
 				room.Phase,
 				room.Players,
-				room.Round,
-				room.Results,
+				room.Game.Round,
+				room.Game.Results,
+				room.Settings,
+				room.Game.StartTime,
 			)
 			if err != nil {
 				var pgError *pgconn.PgError
@@ -112,19 +114,23 @@ values ($1, $2, $3, $4, $5, $6)`,
 			return nil
 		}
 	}
-	_, err := p.conn.Exec(ctx, `insert into rooms (id, nonce, phase, players, round, results)
-values ($1, $2, $3, $4, $5, $6)
+	_, err := p.conn.Exec(ctx, `insert into rooms (id, nonce, phase, players, round, results, settings, started_at)
+values ($1, $2, $3, $4, $5, $6, $7, $8)
 on conflict (id) do update set nonce=excluded.nonce,
                                phase=excluded.phase,
                                players=excluded.players,
                                round=excluded.round,
-                               results=excluded.results`,
+                               results=excluded.results,
+                               settings=excluded.settings,
+                               started_at=excluded.started_at`,
 		room.ID,
 		room.Nonce,
 		room.Phase,
 		room.Players,
-		room.Round,
-		room.Results,
+		room.Game.Round,
+		room.Game.Results,
+		room.Settings,
+		room.Game.StartTime,
 	)
 	if err != nil {
 		return fmt.Errorf("error saving room: %w", err)
@@ -134,16 +140,20 @@ on conflict (id) do update set nonce=excluded.nonce,
 
 func (p *PostgresRoomRepository) Get(id string) (*Room, error) {
 	var room Room
+	game := newGame(Settings{})
 	err := p.conn.QueryRow(
 		context.Background(),
-		"select id, nonce, phase, players, round, results from rooms where id = $1", id,
-	).Scan(&room.ID, &room.Nonce, &room.Phase, &room.Players, &room.Round, &room.Results)
+		"select id, nonce, phase, players, round, results, settings, started_at from rooms where id = $1", id,
+	).Scan(&room.ID, &room.Nonce, &room.Phase, &room.Players, &game.Round, &game.Results, &room.Settings, &game.StartTime)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, errNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("error getting room: %w", err)
 	}
+	game.Length = room.Settings.Length
+	game.Finished = room.Phase == PhaseFinished
+	room.Game = game
 	room.clients = make(map[chan RoomView]string)
 	return &room, nil
 }
@@ -35,19 +35,20 @@ func TestPostgresRoomRepository(t *testing.T) {
 
 		repo := NewPostgresRoomRepository(tx)
 		room := &Room{
-			ID: "ABCD",
-			Results: []mahjong.Result{
-				{
-					Dealer:       1,
-					Wind:         1,
-					Winner:       1,
-					Loser:        -1,
-					Points:       1,
-					WinningTiles: []mahjong.Tile{mahjong.TileDragonsWhite},
-				},
-			},
+			ID:      "ABCD",
+			Game:    newGame(Settings{}),
 			clients: map[chan RoomView]string{},
 		}
+		room.Game.Results = []mahjong.Result{
+			{
+				Dealer:       1,
+				Wind:         1,
+				Winner:       1,
+				Loser:        -1,
+				Points:       1,
+				WinningTiles: []mahjong.Tile{mahjong.TileDragonsWhite},
+			},
+		}
 		err := repo.Save(room)
 		assert.NoError(t, err)
 
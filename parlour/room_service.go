@@ -1,8 +1,12 @@
 package parlour
 
 import (
+	"fmt"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/yi-jiayu/mahjong.go"
 )
 
 type Error struct {
@@ -16,6 +20,8 @@ func (e *Error) Unwrap() error {
 
 type roomService struct {
 	RoomRepository RoomRepository
+	ValidateRounds bool
+	DefaultAI      string
 
 	cache map[string]*Room
 	sync.Mutex
@@ -34,14 +40,19 @@ func (s *roomService) Get(id string) (*Room, error) {
 	}
 	s.cache[room.ID] = room
 
-	// start bots
+	// start bots, unless the game is over
 	for _, player := range room.Players {
-		if player.IsBot {
+		if player.IsBot && room.Phase != PhaseFinished {
+			ai, err := s.newAI(player.AI, room.Game.Rules)
+			if err != nil {
+				fmt.Printf("room=%s bot=%s ai=%s starting with default ai: %v\n", room.ID, player.ID, player.AI, err)
+				ai, _ = s.newAI("", room.Game.Rules)
+			}
 			bot := Bot{
 				ID:      player.ID,
 				Room:    room,
 				Updates: make(chan RoomView, 1),
-				AI:      discardRandomTileAI{},
+				AI:      ai,
 			}
 			room.clients[bot.Updates] = bot.ID
 			go bot.Start(s)
@@ -52,10 +63,12 @@ func (s *roomService) Get(id string) (*Room, error) {
 	return room, nil
 }
 
-func (s *roomService) Create(host Player) (*Room, error) {
+func (s *roomService) Create(host Player, settings Settings) (*Room, error) {
 	s.Lock()
 	defer s.Unlock()
 	room := NewRoom(host)
+	room.Settings = settings
+	room.Game = newGame(settings)
 	err := s.RoomRepository.Save(room)
 	if err != nil {
 		return nil, &Error{
@@ -97,14 +110,86 @@ func (s *roomService) Dispatch(room *Room, playerID string, action Action) error
 			svcErr = &Error{error: err}
 			return
 		}
+		if s.ValidateRounds {
+			s.validate(r)
+		}
 		svcErr = s.RoomRepository.Save(r)
 	})
 	return svcErr
 }
 
+// Rig changes a room's round for testing, then saves the room and sends the
+// change to everyone in it.
+func (s *roomService) Rig(room *Room, rig func(round *mahjong.Round, t time.Time) error) error {
+	var svcErr error
+	room.WithLock(func(r *Room) {
+		if r.Game == nil || r.Game.Round == nil {
+			svcErr = &Error{error: errRoundNotStarted}
+			return
+		}
+		err := rig(r.Game.Round, time.Now())
+		if err != nil {
+			svcErr = &Error{error: err}
+			return
+		}
+		r.Nonce++
+		r.broadcast()
+		svcErr = s.RoomRepository.Save(r)
+	})
+	return svcErr
+}
+
+// aiName returns the name of the AI a bot is added with when it asks for
+// a certain one, which may be empty.
+func (s *roomService) aiName(name string) string {
+	if name != "" {
+		return name
+	}
+	if s.DefaultAI != "" {
+		return s.DefaultAI
+	}
+	return DefaultAI
+}
+
+// newAI returns a new AI by name, or the default AI if name is empty.
+// External AIs fall back to the default AI, unless it is external too.
+func (s *roomService) newAI(name string, rules mahjong.Rules) (AI, error) {
+	ai, err := NewAI(s.aiName(name), rules)
+	if err != nil {
+		return nil, err
+	}
+	external, ok := ai.(*externalAI)
+	if !ok {
+		return ai, nil
+	}
+	fallback, err := NewAI(s.aiName(""), rules)
+	if err != nil {
+		fmt.Printf("ai=%s error creating fallback: %v\n", s.aiName(""), err)
+		return ai, nil
+	}
+	if other, ok := fallback.(*externalAI); ok {
+		_ = other.Close()
+		return ai, nil
+	}
+	external.fallback = fallback
+	return ai, nil
+}
+
+// validate logs any inconsistency in the state of a room's round.
+func (s *roomService) validate(room *Room) {
+	if room.Game == nil || room.Game.Round == nil {
+		return
+	}
+	if err := room.Game.Round.Validate(); err != nil {
+		fmt.Printf("room=%s invalid round: %v\n", room.ID, err)
+	}
+}
+
 var botNames = []string{"Francisco Bot", "Lupe Bot", "Mordecai Bot"}
 
-func (s *roomService) AddBot(room *Room, playerID string) error {
+// AddBot adds a bot to a room which thinks with an AI by name, or the default
+// AI if the name is empty.
+func (s *roomService) AddBot(room *Room, playerID string, aiName string) error {
 	var svcErr error
 	room.WithLock(func(r *Room) {
 		if r.seat(playerID) == -1 {
@@ -115,18 +200,25 @@ func (s *roomService) AddBot(room *Room, playerID string) error {
 			svcErr = &Error{error: errRoomFull}
 			return
 		}
+		aiName = s.aiName(aiName)
+		ai, err := s.newAI(aiName, r.Game.Rules)
+		if err != nil {
+			svcErr = &Error{error: err}
+			return
+		}
 		name := botNames[len(r.Players)-1]
 		r.Players = append(r.Players, Player{
 			ID:    name,
 			Name:  name,
 			IsBot: true,
+			AI:    aiName,
 		})
 		r.broadcast()
 		bot := Bot{
 			ID:      name,
 			Room:    r,
 			Updates: make(chan RoomView),
-			AI:      discardRandomTileAI{},
+			AI:      ai,
 		}
 		r.clients[bot.Updates] = bot.ID
 		go bot.Start(s)
@@ -1,10 +1,15 @@
 package parlour
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yi-jiayu/mahjong.go"
 )
 
 func Test_roomService_Get(t *testing.T) {
@@ -34,3 +39,101 @@ func Test_roomService_Get(t *testing.T) {
 		assert.Same(t, room, got)
 	})
 }
+
+func Test_roomService_AddBot(t *testing.T) {
+	t.Run("adds a bot with the chosen ai", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		roomRepository := NewMockRoomRepository(ctrl)
+		roomRepository.EXPECT().Save(gomock.Any()).Return(nil)
+
+		room := NewRoom(Player{ID: "alice"})
+		service := newRoomService(roomRepository)
+		err := service.AddBot(room, "alice", "hard")
+		assert.NoError(t, err)
+		assert.Equal(t, Player{ID: botNames[0], Name: botNames[0], IsBot: true, AI: "hard"}, room.Players[1])
+	})
+	t.Run("adds a bot with the default ai", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		roomRepository := NewMockRoomRepository(ctrl)
+		roomRepository.EXPECT().Save(gomock.Any()).Return(nil).Times(2)
+
+		room := NewRoom(Player{ID: "alice"})
+		service := newRoomService(roomRepository)
+		assert.NoError(t, service.AddBot(room, "alice", ""))
+		assert.Equal(t, DefaultAI, room.Players[1].AI)
+
+		service.DefaultAI = "cautious"
+		assert.NoError(t, service.AddBot(room, "alice", ""))
+		assert.Equal(t, "cautious", room.Players[2].AI)
+	})
+	t.Run("unknown ai", func(t *testing.T) {
+		room := NewRoom(Player{ID: "alice"})
+		service := newRoomService(nil)
+		err := service.AddBot(room, "alice", "grandmaster")
+		assert.True(t, errors.Is(err, errUnknownAI))
+		assert.Len(t, room.Players, 1)
+	})
+}
+
+func Test_roomService_newAI(t *testing.T) {
+	defer delete(ais, "broken")
+	RegisterAI("broken", ExternalAI(time.Second, "/nonexistent/ai"))
+	t.Run("external ai falls back to the default ai", func(t *testing.T) {
+		service := newRoomService(nil)
+		service.DefaultAI = "easy"
+		ai, err := service.newAI("broken", mahjong.RulesDefault)
+		require.NoError(t, err)
+		require.IsType(t, &externalAI{}, ai)
+		easy, _ := NewAI("easy", mahjong.RulesDefault)
+		assert.Equal(t, easy, ai.(*externalAI).fallback)
+	})
+	t.Run("external default ai is not a fallback", func(t *testing.T) {
+		service := newRoomService(nil)
+		service.DefaultAI = "broken"
+		ai, err := service.newAI("", mahjong.RulesDefault)
+		require.NoError(t, err)
+		require.IsType(t, &externalAI{}, ai)
+		medium, _ := NewAI(DefaultAI, mahjong.RulesDefault)
+		assert.Equal(t, medium, ai.(*externalAI).fallback)
+	})
+}
+
+func Test_roomService_Rig(t *testing.T) {
+	t.Run("rigged rounds are kept when reloaded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		roomRepository := NewMockRoomRepository(ctrl)
+		roomRepository.EXPECT().Save(gomock.Any()).Return(nil)
+
+		room := NewRoom(Player{ID: "alice"})
+		require.NoError(t, room.Game.Start(time.Now()))
+		tile := room.Game.Round.Wall[5]
+		service := newRoomService(roomRepository)
+		err := service.Rig(room, func(round *mahjong.Round, t time.Time) error {
+			return round.StackWall(t, tile)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, room.Nonce)
+
+		data, err := json.Marshal(room.Game.Round)
+		require.NoError(t, err)
+		var reloaded mahjong.Round
+		require.NoError(t, json.Unmarshal(data, &reloaded))
+		assert.Equal(t, tile, reloaded.Wall[0])
+		assert.Equal(t, room.Game.Round.Wall, reloaded.Wall)
+	})
+	t.Run("round must be started", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		roomRepository := NewMockRoomRepository(ctrl)
+
+		room := NewRoom(Player{ID: "alice"})
+		service := newRoomService(roomRepository)
+		err := service.Rig(room, func(round *mahjong.Round, t time.Time) error {
+			return nil
+		})
+		assert.True(t, errors.Is(err, errRoundNotStarted))
+	})
+}
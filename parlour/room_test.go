@@ -1,9 +1,13 @@
 package parlour
 
 import (
+	"errors"
+	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/yi-jiayu/mahjong.go"
 )
 
 func TestRoom_AddPlayer(t *testing.T) {
@@ -44,4 +48,122 @@ func TestRoom_reduce(t *testing.T) {
 		err := r.reduce(player.ID, action)
 		assert.EqualError(t, err, "invalid action")
 	})
+	t.Run("cannot start without enough players", func(t *testing.T) {
+		player := Player{ID: "abc"}
+		r := &Room{
+			Players: []Player{player},
+			Phase:   PhaseLobby,
+		}
+		err := r.reduce(player.ID, Action{Type: ActionNextRound})
+		assert.Equal(t, errNotEnoughPlayers, err)
+	})
+	t.Run("stays in the lobby when the game cannot start", func(t *testing.T) {
+		r := NewRoom(Player{ID: "p0"})
+		r.Players = append(r.Players, Player{ID: "p1"}, Player{ID: "p2"}, Player{ID: "p3"})
+		r.Game.Rules.Tiles = mahjong.TileSet{NoHonours: true, Suits: []mahjong.Suit{mahjong.SuitDots}}
+		err := r.reduce("p0", Action{Type: ActionNextRound})
+		assert.True(t, errors.Is(err, mahjong.ErrTooFewTiles))
+		assert.Equal(t, PhaseLobby, r.Phase)
+		assert.NotPanics(t, func() { r.view("p0") })
+	})
+}
+
+func TestRoom_view(t *testing.T) {
+	newRoom := func(undo UndoPolicy) *Room {
+		r := NewRoom(Player{ID: "p0"})
+		r.Players = append(r.Players, Player{ID: "p1"}, Player{ID: "p2"}, Player{ID: "p3"})
+		r.Settings = Settings{Undo: undo}
+		r.Phase = PhaseInProgress
+		r.Game = mahjong.NewGame(mahjong.RulesDefault, time.Minute, rand.New(rand.NewSource(0)))
+		_ = r.Game.Start(time.Now())
+		var tile mahjong.Tile
+		for tile = range r.Game.Round.Hands[0].Concealed {
+			break
+		}
+		if err := r.reduce("p0", Action{Nonce: r.Nonce, Type: ActionDiscard, Tiles: []mahjong.Tile{tile}}); err != nil {
+			panic(err)
+		}
+		return r
+	}
+	canUndo := func(view RoomView) bool {
+		for _, action := range view.Round.Actions {
+			if action.Type == mahjong.ActionUndo {
+				return true
+			}
+		}
+		return false
+	}
+	t.Run("undo is offered when allowed", func(t *testing.T) {
+		r := newRoom(UndoUnanimous)
+		assert.True(t, canUndo(r.view("p0")))
+	})
+	t.Run("undo is not offered when disabled", func(t *testing.T) {
+		r := newRoom(UndoOff)
+		assert.False(t, canUndo(r.view("p0")))
+	})
+}
+
+func TestRoom_reduceUndo(t *testing.T) {
+	newRoom := func(undo UndoPolicy) (*Room, mahjong.Tile) {
+		r := NewRoom(Player{ID: "p0"})
+		r.Players = append(r.Players, Player{ID: "p1"}, Player{ID: "p2"}, Player{ID: "p3", IsBot: true})
+		r.Settings = Settings{Undo: undo}
+		r.Phase = PhaseInProgress
+		r.Game = mahjong.NewGame(mahjong.RulesDefault, time.Minute, rand.New(rand.NewSource(0)))
+		_ = r.Game.Start(time.Now())
+		var tile mahjong.Tile
+		for tile = range r.Game.Round.Hands[0].Concealed {
+			break
+		}
+		err := r.reduce("p0", Action{Nonce: r.Nonce, Type: ActionDiscard, Tiles: []mahjong.Tile{tile}})
+		if err != nil {
+			panic(err)
+		}
+		return r, tile
+	}
+	t.Run("undo disabled", func(t *testing.T) {
+		r, _ := newRoom(UndoOff)
+		err := r.reduce("p0", Action{Nonce: r.Nonce, Type: ActionUndo})
+		assert.True(t, errors.Is(err, errUndoDisabled))
+	})
+	t.Run("cannot undo another player's action", func(t *testing.T) {
+		r, _ := newRoom(UndoUnanimous)
+		err := r.reduce("p1", Action{Nonce: r.Nonce, Type: ActionUndo})
+		assert.True(t, errors.Is(err, mahjong.ErrNothingToUndo))
+	})
+	t.Run("undo after unanimous consent", func(t *testing.T) {
+		r, tile := newRoom(UndoUnanimous)
+		count := r.Game.Round.Hands[0].Concealed[tile]
+		err := r.reduce("p0", Action{Nonce: r.Nonce, Type: ActionUndo})
+		assert.NoError(t, err)
+		assert.Equal(t, [4]bool{true, false, false, true}, r.UndoRequest.Approvals)
+		err = r.reduce("p1", Action{Nonce: r.Nonce, Type: ActionApproveUndo})
+		assert.NoError(t, err)
+		assert.NotNil(t, r.UndoRequest)
+		err = r.reduce("p2", Action{Nonce: r.Nonce, Type: ActionApproveUndo})
+		assert.NoError(t, err)
+		assert.Nil(t, r.UndoRequest)
+		assert.Empty(t, r.Game.Round.Discards)
+		assert.Equal(t, count+1, r.Game.Round.Hands[0].Concealed[tile])
+		assert.Equal(t, 0, r.Game.Round.Turn)
+		assert.Equal(t, mahjong.PhaseDiscard, r.Game.Round.Phase)
+	})
+	t.Run("rejected undo", func(t *testing.T) {
+		r, tile := newRoom(UndoUnanimous)
+		_ = r.reduce("p0", Action{Nonce: r.Nonce, Type: ActionUndo})
+		err := r.reduce("p2", Action{Nonce: r.Nonce, Type: ActionRejectUndo})
+		assert.NoError(t, err)
+		assert.Nil(t, r.UndoRequest)
+		assert.Equal(t, []mahjong.Tile{tile}, r.Game.Round.Discards)
+	})
+	t.Run("other actions cancel undo request", func(t *testing.T) {
+		r, _ := newRoom(UndoUnanimous)
+		_ = r.reduce("p0", Action{Nonce: r.Nonce, Type: ActionUndo})
+		r.Game.Round.ReservedDuration = 0
+		err := r.reduce("p1", Action{Nonce: r.Nonce, Type: ActionDraw})
+		assert.NoError(t, err)
+		assert.Nil(t, r.UndoRequest)
+		err = r.reduce("p2", Action{Nonce: r.Nonce, Type: ActionApproveUndo})
+		assert.True(t, errors.Is(err, errNoUndoRequest))
+	})
 }
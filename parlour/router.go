@@ -70,12 +70,15 @@ func handleErrors(c *gin.Context) {
 	if errors.As(err.Err, &e) {
 		if e.internal {
 			fmt.Printf("internal error: %v", e)
-			c.String(http.StatusInternalServerError, "internal error")
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Code:    CodeInternalError,
+				Message: "internal error",
+			})
 			return
 		}
 		_ = err.SetType(gin.ErrorTypePublic)
 	}
-	c.String(http.StatusBadRequest, err.Error())
+	c.JSON(http.StatusBadRequest, newErrorResponse(err.Err))
 }
 
 func getName(c *gin.Context) (string, error) {
@@ -89,6 +92,43 @@ func getName(c *gin.Context) (string, error) {
 	return name, nil
 }
 
+func getSettings(c *gin.Context) (Settings, error) {
+	undo := UndoPolicy(c.DefaultPostForm("undo", string(UndoOff)))
+	if undo != UndoOff && undo != UndoUnanimous {
+		return Settings{}, errors.New("undo is invalid")
+	}
+	var length mahjong.GameLength
+	var err error
+	if winds := c.PostForm("winds"); winds != "" {
+		length.Winds, err = strconv.Atoi(winds)
+		if err != nil || length.Winds < 1 || length.Winds > 4 {
+			return Settings{}, errors.New("winds is invalid")
+		}
+	}
+	if hands := c.PostForm("hands"); hands != "" {
+		length.Hands, err = strconv.Atoi(hands)
+		if err != nil || length.Hands < 1 {
+			return Settings{}, errors.New("hands is invalid")
+		}
+	}
+	if timeLimit := c.PostForm("time_limit"); timeLimit != "" {
+		length.Duration, err = time.ParseDuration(timeLimit)
+		if err != nil || length.Duration <= 0 {
+			return Settings{}, errors.New("time_limit is invalid")
+		}
+	}
+	if bust := c.PostForm("bust_threshold"); bust != "" {
+		length.BustThreshold, err = strconv.Atoi(bust)
+		if err != nil || length.BustThreshold < 1 {
+			return Settings{}, errors.New("bust_threshold is invalid")
+		}
+	}
+	return Settings{
+		Undo:   undo,
+		Length: length,
+	}, nil
+}
+
 func (p *Parlour) createRoomHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		playerID := c.GetString(KeyPlayerID)
@@ -97,11 +137,16 @@ func (p *Parlour) createRoomHandler() gin.HandlerFunc {
 			_ = c.Error(err)
 			return
 		}
+		settings, err := getSettings(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
 		player := Player{
 			ID:   playerID,
 			Name: name,
 		}
-		room, err := p.roomService.Create(player)
+		room, err := p.roomService.Create(player, settings)
 		if err != nil {
 			_ = c.Error(err)
 			return
@@ -188,13 +233,13 @@ func (p *Parlour) roomActionsHandler() gin.HandlerFunc {
 	}
 }
 
-func setConcealedHandler() gin.HandlerFunc {
+// The handlers below rig rounds for testing and are only available in debug
+// mode. Changes are recorded in a round's events so that they are kept when
+// it is saved.
+
+func (p *Parlour) setConcealedHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		room := c.MustGet(KeyRoom).(*Room)
-		if room.Round == nil {
-			c.String(http.StatusBadRequest, "round not started")
-			return
-		}
 		seat, err := strconv.Atoi(c.Param("seat"))
 		if err != nil {
 			c.String(http.StatusBadRequest, "invalid seat")
@@ -210,38 +255,44 @@ func setConcealedHandler() gin.HandlerFunc {
 			_ = c.Error(err)
 			return
 		}
-		room.Round.Hands[seat].Concealed = tiles
+		err = p.roomService.Rig(room, func(round *mahjong.Round, t time.Time) error {
+			return round.SwapConcealed(seat, t, tiles)
+		})
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
 	}
 }
 
-func prependWallHandler() gin.HandlerFunc {
+func (p *Parlour) prependWallHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		room := c.MustGet(KeyRoom).(*Room)
-		if room.Round == nil {
-			c.String(http.StatusBadRequest, "round not started")
-			return
-		}
 		tile := c.PostForm("tile")
 		if tile == "" {
 			c.String(http.StatusBadRequest, "tile is required")
 			return
 		}
-		room.Round.Wall = append([]mahjong.Tile{mahjong.Tile(tile)}, room.Round.Wall...)
+		err := p.roomService.Rig(room, func(round *mahjong.Round, t time.Time) error {
+			return round.StackWall(t, mahjong.Tile(tile))
+		})
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
 	}
 }
 
-func reshuffleHandler() gin.HandlerFunc {
+func (p *Parlour) reshuffleHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		room := c.MustGet(KeyRoom).(*Room)
-		if room.Round == nil {
-			c.String(http.StatusBadRequest, "round not started")
+		err := p.roomService.Rig(room, func(round *mahjong.Round, t time.Time) error {
+			return round.Redeal(mathRand.Int63(), t)
+		})
+		if err != nil {
+			_ = c.Error(err)
 			return
 		}
-		room.Round = &mahjong.Round{
-			Rules: mahjong.RulesDefault,
-		}
-		room.Round.Start(mathRand.Int63(), time.Now())
-		room.broadcast()
 	}
 }
 
@@ -249,7 +300,7 @@ func (p *Parlour) addBotHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		playerID := c.GetString(KeyPlayerID)
 		room := c.MustGet(KeyRoom).(*Room)
-		err := p.roomService.AddBot(room, playerID)
+		err := p.roomService.AddBot(room, playerID, c.PostForm("ai"))
 		if err != nil {
 			_ = c.Error(err)
 			return
@@ -276,6 +327,8 @@ func (p *Parlour) setRoomMiddleware() gin.HandlerFunc {
 }
 
 func (p Parlour) configure(r *gin.Engine) {
+	p.roomService.ValidateRounds = p.ValidateRounds
+	p.roomService.DefaultAI = p.DefaultAI
 	r.Use(sessions.Sessions(KeySessionName, p.SessionStore))
 	r.Use(setPlayerID)
 	r.Use(handleErrors)
@@ -289,9 +342,9 @@ func (p Parlour) configure(r *gin.Engine) {
 		room.POST("/actions", p.roomActionsHandler())
 		room.POST("/bots", p.addBotHandler())
 		if gin.IsDebugging() {
-			room.PUT("/round/hands/:seat/concealed", setConcealedHandler())
-			room.POST("/round/wall", prependWallHandler())
-			room.POST("/round/reshuffle", reshuffleHandler())
+			room.PUT("/round/hands/:seat/concealed", p.setConcealedHandler())
+			room.POST("/round/wall", p.prependWallHandler())
+			room.POST("/round/reshuffle", p.reshuffleHandler())
 		}
 	}
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
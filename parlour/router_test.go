@@ -6,11 +6,13 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-contrib/sessions/memstore"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/yi-jiayu/mahjong.go"
 )
 
 //go:generate ../bin/mockgen -destination mocks_test.go -package parlour -self_package github.com/yi-jiayu/mahjong.go/parlour . RoomRepository
@@ -40,6 +42,59 @@ func TestParlour_createRoomHandler(t *testing.T) {
 	assert.Equal(t, roomID, w.Body.String())
 }
 
+func TestParlour_createRoomHandler_settings(t *testing.T) {
+	t.Run("saves settings", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		var saved *Room
+		roomRepository := NewMockRoomRepository(ctrl)
+		roomRepository.EXPECT().Save(gomock.Any()).DoAndReturn(func(room *Room) error {
+			room.ID = "ABCD"
+			saved = room
+			return nil
+		})
+
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		parlour := New(roomRepository, memstore.NewStore())
+		parlour.configure(router)
+
+		w := httptest.NewRecorder()
+		body := "name=alice&undo=unanimous&winds=1&hands=8&time_limit=90m&bust_threshold=50"
+		req, _ := http.NewRequest(http.MethodPost, "/rooms", strings.NewReader(body))
+		req.Header.Set("content-type", "application/x-www-form-urlencoded")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		length := mahjong.GameLength{
+			Winds:         1,
+			Hands:         8,
+			Duration:      90 * time.Minute,
+			BustThreshold: 50,
+		}
+		assert.Equal(t, Settings{Undo: UndoUnanimous, Length: length}, saved.Settings)
+		assert.Equal(t, length, saved.Game.Length)
+	})
+	t.Run("invalid settings", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		roomRepository := NewMockRoomRepository(ctrl)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		parlour := New(roomRepository, memstore.NewStore())
+		parlour.configure(router)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/rooms", strings.NewReader("name=alice&winds=5"))
+		req.Header.Set("content-type", "application/x-www-form-urlencoded")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"code": "bad_request", "message": "winds is invalid"}`, w.Body.String())
+	})
+}
+
 func TestParlour_joinRoomHandler(t *testing.T) {
 	room := NewRoom(Player{Name: "alice"})
 	room.ID = "ABCD"
@@ -0,0 +1,118 @@
+package mahjong
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Player decides what a player does during a round. Players can be driven by
+// PlayRound and PlayGame without a server.
+type Player interface {
+	// Decide returns the action to take out of the legal actions available
+	// to the viewing player, or nil to pass on claiming a discard. A player
+	// may not pass when they have to draw, discard or end the round.
+	Decide(view RoundView, actions []Action) *Action
+}
+
+// maxSteps is the number of actions after which PlayRound gives up on a
+// round ever finishing.
+const maxSteps = 1000
+
+// Reasons a round may not be played to the end.
+var (
+	ErrNoDecision   = errors.New("nobody acted")
+	ErrTooManySteps = errors.New("too many steps")
+)
+
+// claimPrecedence ranks actions taken at the same time: a win beats a pong
+// or gang, which beats anything else.
+func claimPrecedence(action Action) int {
+	switch action.Type {
+	case ActionHu:
+		return 2
+	case ActionPong, ActionGang:
+		return 1
+	}
+	return 0
+}
+
+// MustAct reports whether a player has to take one of certain actions rather
+// than pass.
+func MustAct(actions []Action) bool {
+	for _, action := range actions {
+		switch action.Type {
+		case ActionDraw, ActionDiscard, ActionEnd:
+			return true
+		}
+	}
+	return false
+}
+
+// playStep asks every player with legal actions apart from undo for a
+// decision in turn order, once the reserved duration after the last action is
+// over, and takes the decision with the highest precedence.
+func playStep(r *Round, decide func(seat int, actions []Action) *Action) error {
+	t := r.LastActionTime.Add(r.ReservedDuration)
+	var decision *Action
+	decider := -1
+	for i := 0; i < 4; i++ {
+		seat := (r.Turn + i) % 4
+		var actions []Action
+		for _, action := range r.LegalActions(seat, t) {
+			if action.Type != ActionUndo {
+				actions = append(actions, action)
+			}
+		}
+		if len(actions) == 0 {
+			continue
+		}
+		action := decide(seat, actions)
+		if action != nil && (decision == nil || claimPrecedence(*action) > claimPrecedence(*decision)) {
+			decision, decider = action, seat
+		}
+	}
+	if decision == nil {
+		return ErrNoDecision
+	}
+	if err := r.Act(decider, t, *decision); err != nil {
+		return fmt.Errorf("seat %d could not %s: %w", decider, decision.Type, err)
+	}
+	return nil
+}
+
+// PlayRound plays a started round to the end, one step at a time. Players
+// are given the view of the round from their seat.
+func PlayRound(r *Round, players [4]Player) error {
+	decide := func(seat int, actions []Action) *Action {
+		return players[seat].Decide(r.View(seat), actions)
+	}
+	for steps := 0; !r.Finished; steps++ {
+		if steps == maxSteps {
+			return ErrTooManySteps
+		}
+		if err := playStep(r, decide); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlayGame plays a game to the end, starting it at a certain time if it has
+// not been started yet. Each round starts when the previous one ended.
+func PlayGame(g *Game, t time.Time, players [4]Player) error {
+	if g.Round == nil {
+		if err := g.Start(t); err != nil {
+			return err
+		}
+	}
+	for !g.Finished {
+		if err := PlayRound(g.Round, players); err != nil {
+			return err
+		}
+		if err := g.NextRound(g.Round.LastActionTime); err != nil && err != ErrNoMoreRounds {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package mahjong
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// firstActionPlayer wins whenever it can and otherwise takes the first legal
+// action, passing on claims.
+type firstActionPlayer struct {
+	views []RoundView
+}
+
+func (p *firstActionPlayer) Decide(view RoundView, actions []Action) *Action {
+	p.views = append(p.views, view)
+	for _, action := range actions {
+		if action.Type == ActionHu {
+			return &action
+		}
+	}
+	for _, action := range actions {
+		switch action.Type {
+		case ActionDraw, ActionDiscard, ActionEnd:
+			return &action
+		}
+	}
+	return nil
+}
+
+// passingPlayer never does anything.
+type passingPlayer struct{}
+
+func (passingPlayer) Decide(view RoundView, actions []Action) *Action {
+	return nil
+}
+
+// playerFunc lets a function be used as a player.
+type playerFunc func(view RoundView, actions []Action) *Action
+
+func (f playerFunc) Decide(view RoundView, actions []Action) *Action {
+	return f(view, actions)
+}
+
+func newFirstActionPlayers() [4]Player {
+	var players [4]Player
+	for i := range players {
+		players[i] = &firstActionPlayer{}
+	}
+	return players
+}
+
+func TestPlayRound(t *testing.T) {
+	t.Run("plays a round to the end", func(t *testing.T) {
+		r := &Round{Rules: RulesDefault, ReservedDuration: time.Second}
+		r.Start(1, time.Unix(0, 0))
+		players := newFirstActionPlayers()
+		err := PlayRound(r, players)
+		require.NoError(t, err)
+		assert.True(t, r.Finished)
+		assert.NotNil(t, r.Result)
+		assert.NoError(t, r.Validate())
+		for seat, player := range players {
+			for _, view := range player.(*firstActionPlayer).views {
+				assert.Equal(t, seat, view.Seat)
+			}
+		}
+	})
+	t.Run("claims with the highest precedence win", func(t *testing.T) {
+		now := time.Now()
+		r := &Round{
+			Rules:          RulesDefault,
+			Wall:           make([]Tile, 10),
+			Turn:           1,
+			Phase:          PhaseDraw,
+			Discards:       []Tile{TileDragonsRed},
+			LastActionTime: now,
+			Hands: [4]Hand{{}, {
+				Concealed: NewTileBag([]Tile{TileBamboo1, TileBamboo2}),
+			}, {}, {
+				Concealed: NewTileBag([]Tile{TileDragonsRed, TileDragonsRed, TileBamboo5}),
+			}},
+		}
+		var asked []int
+		player := playerFunc(func(view RoundView, actions []Action) *Action {
+			asked = append(asked, view.Seat)
+			if view.Phase == PhaseDiscard {
+				return nil
+			}
+			return &actions[0]
+		})
+		err := PlayRound(r, [4]Player{player, player, player, player})
+		assert.Equal(t, ErrNoDecision, err)
+		assert.Equal(t, []int{1, 3, 3}, asked)
+		assert.Equal(t, 3, r.Turn)
+		assert.Equal(t, PhaseDiscard, r.Phase)
+		assert.Len(t, r.Hands[3].Revealed, 1)
+	})
+	t.Run("nobody acted", func(t *testing.T) {
+		r := &Round{Rules: RulesDefault}
+		r.Start(1, time.Unix(0, 0))
+		err := PlayRound(r, [4]Player{passingPlayer{}, passingPlayer{}, passingPlayer{}, passingPlayer{}})
+		assert.Equal(t, ErrNoDecision, err)
+	})
+	t.Run("illegal action", func(t *testing.T) {
+		r := &Round{Rules: RulesDefault}
+		r.Start(1, time.Unix(0, 0))
+		player := playerFunc(func(view RoundView, actions []Action) *Action {
+			return &Action{Type: ActionHu}
+		})
+		err := PlayRound(r, [4]Player{player, player, player, player})
+		assert.Error(t, err)
+	})
+}
+
+func TestPlayGame(t *testing.T) {
+	g := NewGame(RulesDefault, time.Second, rand.New(rand.NewSource(1)))
+	g.Length.Hands = 3
+	err := PlayGame(g, time.Unix(0, 0), newFirstActionPlayers())
+	require.NoError(t, err)
+	assert.True(t, g.Finished)
+	assert.Len(t, g.Results, 3)
+}
+
+func TestMustAct(t *testing.T) {
+	assert.True(t, MustAct([]Action{{Type: ActionUndo}, {Type: ActionDraw}, {Type: ActionChi}}))
+	assert.True(t, MustAct([]Action{{Type: ActionDiscard}, {Type: ActionGang}}))
+	assert.True(t, MustAct([]Action{{Type: ActionEnd}}))
+	assert.False(t, MustAct([]Action{{Type: ActionPong}, {Type: ActionHu}}))
+	assert.False(t, MustAct(nil))
+}
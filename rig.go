@@ -0,0 +1,111 @@
+package mahjong
+
+import (
+	"time"
+)
+
+// Rounds can be rigged for testing by rearranging the tiles in them or dealing
+// them again. Tiles are only ever moved between the wall and players' hands,
+// so that tiles are still conserved, and each change is recorded as an event
+// so that it survives replaying a round.
+
+// StackWall moves a tile in the live wall to the front of the wall, so that it
+// is the next tile drawn.
+func (r *Round) StackWall(t time.Time, tile Tile) error {
+	if r.Finished {
+		return ErrRoundFinished
+	}
+	if !contains(r.Wall, tile) {
+		return ErrMissingTiles
+	}
+	e := newEvent(EventStack, r.Turn, t, tile)
+	e.Visibility = VisibilityHidden
+	r.emit(e)
+	return nil
+}
+
+// SwapConcealed replaces a player's concealed tiles with others of the same
+// number. Tiles added to their hand are taken from the live wall, and the
+// tiles they replace are put back in their place.
+func (r *Round) SwapConcealed(seat int, t time.Time, tiles TileBag) error {
+	if r.Finished {
+		return ErrRoundFinished
+	}
+	if tiles.Cardinality() != r.Hands[seat].Concealed.Cardinality() {
+		return ErrWrongHandSize
+	}
+	added, _ := swappedTiles(r.Hands[seat].Concealed, tiles)
+	wall := NewTileBag(r.Wall)
+	for _, tile := range added {
+		if !wall.RemoveN(tile, 1) {
+			return ErrMissingTiles
+		}
+	}
+	r.emit(newPrivateEvent(EventSwap, seat, t, sortedBag(tiles)...))
+	return nil
+}
+
+// swappedTiles returns the tiles which are in a new hand but not in an old
+// one and those which are in the old hand but not in the new one, in order.
+func swappedTiles(old, new TileBag) (added, removed []Tile) {
+	for _, tile := range sortedTiles(new) {
+		for i := old.Count(tile); i < new.Count(tile); i++ {
+			added = append(added, tile)
+		}
+	}
+	for _, tile := range sortedTiles(old) {
+		for i := new.Count(tile); i < old.Count(tile); i++ {
+			removed = append(removed, tile)
+		}
+	}
+	return
+}
+
+// Redeal starts a round again from a newly shuffled wall. The round keeps the
+// dealer, prevailing wind, streak and scores it started with.
+func (r *Round) Redeal(seed int64, t time.Time) error {
+	if r.Finished {
+		return ErrRoundFinished
+	}
+	scores := r.Scores
+	if len(r.Events) > 0 && r.Events[0].Scores != nil {
+		// leave out payouts for flowers during the round
+		scores = *r.Events[0].Scores
+	}
+	*r = Round{
+		Scores:           scores,
+		Dealer:           r.Dealer,
+		Wind:             r.Wind,
+		Streak:           r.Streak,
+		Rules:            r.Rules,
+		ReservedDuration: r.ReservedDuration,
+	}
+	r.Start(seed, t)
+	return nil
+}
+
+func (r *Round) applyStack(e Event) {
+	tile := e.Tiles[0]
+	for i, t := range r.Wall {
+		if t == tile {
+			wall := append([]Tile{tile}, r.Wall[:i]...)
+			r.Wall = append(wall, r.Wall[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *Round) applySwap(e Event) {
+	hand := &r.Hands[e.Seat]
+	tiles := NewTileBag(e.Tiles)
+	added, removed := swappedTiles(hand.Concealed, tiles)
+	for i, tile := range added {
+		for j, t := range r.Wall {
+			if t == tile {
+				r.Wall[j] = removed[i]
+				break
+			}
+		}
+	}
+	hand.Concealed = tiles
+}
@@ -0,0 +1,94 @@
+package mahjong
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRound_StackWall(t *testing.T) {
+	t.Run("moves a tile to the front of the wall", func(t *testing.T) {
+		r := &Round{}
+		r.Start(0, time.Now())
+		tile := r.Wall[10]
+		size := len(r.Wall)
+		require.NoError(t, r.StackWall(time.Now(), tile))
+		assert.Equal(t, tile, r.Wall[0])
+		assert.Len(t, r.Wall, size)
+		assert.NoError(t, r.Validate())
+		replayed := &Round{}
+		require.NoError(t, replayed.Replay(r.Events))
+		assert.Equal(t, r.Wall, replayed.Wall)
+	})
+	t.Run("tile must be in the wall", func(t *testing.T) {
+		r := &Round{Wall: []Tile{TileDots1}}
+		assert.Equal(t, ErrMissingTiles, r.StackWall(time.Now(), TileDots2))
+	})
+}
+
+func TestRound_SwapConcealed(t *testing.T) {
+	t.Run("exchanges tiles with the wall", func(t *testing.T) {
+		r := &Round{}
+		r.Start(0, time.Now())
+		seat := (r.Dealer + 1) % 4
+		var tiles []Tile
+		for tile, count := range r.Hands[seat].Concealed {
+			for i := 0; i < count; i++ {
+				tiles = append(tiles, tile)
+			}
+		}
+		tiles = append(tiles[2:], r.Wall[3], r.Wall[7])
+		hand := NewTileBag(tiles)
+		require.NoError(t, r.SwapConcealed(seat, time.Now(), hand))
+		assert.Equal(t, hand, r.Hands[seat].Concealed)
+		assert.NoError(t, r.Validate())
+		replayed := &Round{}
+		require.NoError(t, replayed.Replay(r.Events))
+		assert.Equal(t, r.Hands, replayed.Hands)
+		assert.Equal(t, r.Wall, replayed.Wall)
+		assert.Nil(t, r.View((seat + 1) % 4).Events[len(r.Events)-1].Tiles)
+	})
+	t.Run("hand size must not change", func(t *testing.T) {
+		r := &Round{Hands: [4]Hand{{Concealed: TileBag{TileDots1: 1}}}}
+		assert.Equal(t, ErrWrongHandSize, r.SwapConcealed(0, time.Now(), TileBag{TileDots1: 2}))
+	})
+	t.Run("tiles must be in the wall", func(t *testing.T) {
+		r := &Round{
+			Wall:  []Tile{TileDots2},
+			Hands: [4]Hand{{Concealed: TileBag{TileDots1: 1}}},
+		}
+		assert.Equal(t, ErrMissingTiles, r.SwapConcealed(0, time.Now(), TileBag{TileDots3: 1}))
+	})
+}
+
+func TestRound_Redeal(t *testing.T) {
+	t.Run("keeps the dealer, wind, streak and starting scores", func(t *testing.T) {
+		r := &Round{
+			Scores:           [4]int{10, -5, 0, -5},
+			Dealer:           2,
+			Wind:             DirectionSouth,
+			Streak:           1,
+			Rules:            RulesShooter,
+			ReservedDuration: time.Second,
+		}
+		r.Start(0, time.Now())
+		r.Scores[0] += 2
+		wall := r.Wall
+		require.NoError(t, r.Redeal(1, time.Now()))
+		assert.NotEqual(t, wall, r.Wall)
+		assert.Equal(t, [4]int{10, -5, 0, -5}, r.Scores)
+		assert.Equal(t, 2, r.Dealer)
+		assert.Equal(t, 2, r.Turn)
+		assert.Equal(t, DirectionSouth, r.Wind)
+		assert.Equal(t, 1, r.Streak)
+		assert.Equal(t, RulesShooter, r.Rules)
+		assert.Equal(t, time.Second, r.ReservedDuration)
+		assert.NoError(t, r.Validate())
+	})
+	t.Run("cannot redeal a finished round", func(t *testing.T) {
+		r := &Round{Finished: true}
+		assert.Equal(t, ErrRoundFinished, r.Redeal(0, time.Now()))
+	})
+}
@@ -1,21 +1,23 @@
 package mahjong
 
 import (
+	"encoding/json"
 	"errors"
 	"math/rand"
 	"sort"
 	"time"
 )
 
-const (
-	MinTilesLeft = 16
-)
-
 var (
-	ErrNoMoreRounds = errors.New("no more rounds")
+	ErrNoMoreRounds   = errors.New("no more rounds")
+	ErrUnfinished     = errors.New("unfinished")
+	ErrAlreadyStarted = errors.New("already started")
+	ErrNotStarted     = errors.New("not started")
 )
 
-// Round represents a round in a mahjong game.
+// Round represents a round in a mahjong game. The state of a round is
+// derived from its events, and every change to it is made by emitting an
+// event.
 type Round struct {
 	// Scores contains the score for each player in the game.
 	Scores [4]int
@@ -26,22 +28,46 @@ type Round struct {
 	// Wall contains the remaining tiles left to be drawn.
 	Wall []Tile
 
-	// Discards contains all the previously discarded tiles.
+	// DeadWall contains the tiles reserved for replacement draws.
+	DeadWall []Tile
+
+	// Dice is the result of the dice rolled to break the wall.
+	Dice []int
+
+	// Discards contains all the previously discarded tiles which have not
+	// been claimed.
 	Discards []Tile
 
+	// Rivers contains the tiles discarded by each player in order,
+	// including those claimed by other players.
+	Rivers [4][]DiscardedTile
+
+	// drawn is the tile most recently drawn by the player whose turn it is.
+	drawn Tile
+
+	// lastAction is the most recent action in effect, or nil if there is
+	// none, and lastActionDrew indicates whether it drew tiles.
+	lastAction     *Event
+	lastActionDrew bool
+
 	// Wind is the prevailing wind for the round.
 	Wind Direction
 
 	// Dealer is the integer offset of the dealer for the round.
 	Dealer int
 
+	// Streak is the number of consecutive rounds before this one which the
+	// dealer has kept being the dealer for (連莊).
+	Streak int
+
 	// Turn is the integer offset of the player whose turn it currently is.
 	Turn int
 
 	// Phase is the current turn phase.
 	Phase Phase
 
-	// Events contains all the events that happened in the round.
+	// Events contains all the events that happened in the round. They are the
+	// source of truth for the rest of the state of the round.
 	Events []Event
 
 	// Result is the outcome of the round.
@@ -57,6 +83,11 @@ type Round struct {
 	// precedence can hu after someone else has already done so.
 	WinningTile Tile
 
+	// Passed contains the winning tiles each player let go by since they
+	// last drew or claimed a tile. It is only tracked when the rules
+	// include SacredDiscard.
+	Passed [4][]Tile
+
 	LastActionTime   time.Time
 	ReservedDuration time.Duration
 }
@@ -74,226 +105,246 @@ func (r *Round) popLastDiscard() Tile {
 	return tile
 }
 
-func (r *Round) drawFront() Tile {
-	drawn := r.Wall[0]
-	r.Wall = r.Wall[1:]
-	return drawn
-}
-
-func (r *Round) drawFrontN(n int) []Tile {
-	draws := r.Wall[:n]
-	r.Wall = r.Wall[n:]
-	return draws
+// claimLastDiscard takes the last discard for a player and marks it as
+// claimed by them in the discarder's river.
+func (r *Round) claimLastDiscard(seat int) Tile {
+	r.markClaimed(seat)
+	return r.popLastDiscard()
 }
 
-func (r *Round) drawBack() Tile {
-	drawn := r.Wall[len(r.Wall)-1]
-	r.Wall = r.Wall[:len(r.Wall)-1]
-	return drawn
+// markClaimed marks the last tile in the river of the player before the
+// current turn as claimed by a player.
+func (r *Round) markClaimed(seat int) {
+	river := r.Rivers[r.previousTurn()]
+	if len(river) > 0 {
+		river[len(river)-1].ClaimedBy = &seat
+	}
 }
 
 func (r *Round) previousTurn() int {
 	return (r.Turn + 3) % 4
 }
 
+// replaceTile draws replacement tiles from the back of the wall for a player
+// until they draw a tile which is not a flower. The round is drawn if there
+// are no tiles left to replace with.
 func (r *Round) replaceTile(seat int, t time.Time) {
-	drawn := r.drawBack()
-	for isFlower(drawn) {
+	for {
+		if len(r.DeadWall) == 0 {
+			r.abort(seat, t, ReasonWallExhausted)
+			return
+		}
+		drawn := r.nextReplacement()
+		r.emit(newPrivateEvent(EventReplace, seat, t, drawn))
+		if !r.Rules.Tiles.isFlower(drawn) {
+			return
+		}
 		r.addFlower(seat, t, drawn)
-		drawn = r.drawBack()
 	}
-	r.Hands[seat].Concealed.Add(drawn)
 }
 
 func (r *Round) seatWind(seat int) Direction {
 	return Direction((seat - r.Dealer + 4) % 4)
 }
 
-func (r *Round) Draw(seat int, t time.Time) error {
+func (r *Round) canDraw(seat int, t time.Time) error {
+	if r.Finished {
+		return ErrRoundFinished
+	}
 	if r.Turn != seat {
-		return errors.New("wrong turn")
+		return ErrWrongTurn
 	}
 	if r.Phase != PhaseDraw {
-		return errors.New("wrong phase")
+		return ErrWrongPhase
 	}
 	if t.Before(r.LastActionTime.Add(r.ReservedDuration)) {
-		return errors.New("cannot draw during reserved duration")
+		return ErrReservedDuration
 	}
-	r.Events = append(r.Events, Event{
-		Type: EventDraw,
-		Seat: seat,
-		Time: timeInMillis(t),
-	})
-	drawn := r.drawFront()
-	for isFlower(drawn) {
+	return nil
+}
+
+func (r *Round) Draw(seat int, t time.Time) error {
+	if err := r.canDraw(seat, t); err != nil {
+		return newActionError(seat, ActionDraw, err)
+	}
+	drawn := r.Wall[0]
+	r.emit(newPrivateEvent(EventDraw, seat, t, drawn))
+	if r.Rules.Tiles.isFlower(drawn) {
 		r.addFlower(seat, t, drawn)
-		drawn = r.drawBack()
+		r.replaceTile(seat, t)
 	}
-	hand := &r.Hands[seat]
-	hand.Concealed.Add(drawn)
-	r.Phase = PhaseDiscard
 	r.LastActionTime = t
 	return nil
 }
 
-func (r *Round) Discard(seat int, t time.Time, tile Tile) error {
+func (r *Round) canDiscard(seat int, tile Tile) error {
+	if r.Finished {
+		return ErrRoundFinished
+	}
 	if seat != r.Turn {
-		return errors.New("wrong turn")
+		return ErrWrongTurn
 	}
 	if r.Phase != PhaseDiscard {
-		return errors.New("wrong phase")
+		return ErrWrongPhase
 	}
 	if !r.Hands[seat].Concealed.Contains(tile) {
-		return errors.New("missing tiles")
+		return ErrMissingTiles
+	}
+	if len(r.Wall) == 0 {
+		return ErrNoDrawsLeft
 	}
-	if len(r.Wall) <= MinTilesLeft-1 {
-		return errors.New("no draws left")
+	return nil
+}
+
+func (r *Round) Discard(seat int, t time.Time, tile Tile) error {
+	if err := r.canDiscard(seat, tile); err != nil {
+		return newActionError(seat, ActionDiscard, err)
+	}
+	r.emit(newEvent(EventDiscard, seat, t, tile))
+	if r.fourWinds() {
+		r.abort(seat, t, ReasonFourWinds, tile)
 	}
-	r.Hands[seat].Concealed.Remove(tile)
-	r.Discards = append(r.Discards, tile)
-	r.Turn = (r.Turn + 1) % 4
-	r.Phase = PhaseDraw
-	r.Events = append(r.Events, newEvent(EventDiscard, seat, t, tile))
 	r.LastActionTime = t
 	return nil
 }
 
-func (r *Round) Chi(seat int, t time.Time, tile1, tile2 Tile) error {
+func (r *Round) canChi(seat int, t time.Time, tile1, tile2 Tile) error {
 	if r.Finished {
-		return errors.New("round finished")
+		return ErrRoundFinished
 	}
 	if r.Turn != seat {
-		return errors.New("wrong turn")
+		return ErrWrongTurn
 	}
 	if r.Phase != PhaseDraw {
-		return errors.New("wrong phase")
+		return ErrWrongPhase
 	}
 	if len(r.Discards) == 0 {
-		return errors.New("no discards")
+		return ErrNoDiscards
 	}
 	tile0 := r.lastDiscard()
 	if !isValidSequence(tile0, tile1, tile2) {
-		return errors.New("invalid sequence")
-
+		return ErrInvalidSequence
 	}
 	hand := &r.Hands[seat]
 	if !hand.Concealed.Contains(tile1) || !hand.Concealed.Contains(tile2) {
-		return errors.New("missing tiles")
+		return ErrMissingTiles
 	}
 	if t.Before(r.LastActionTime.Add(r.ReservedDuration)) {
-		return errors.New("cannot chi during reserved duration")
+		return ErrReservedDuration
 	}
-	hand.Concealed.Remove(tile1)
-	hand.Concealed.Remove(tile2)
-	r.popLastDiscard()
-	seq := []Tile{tile0, tile1, tile2}
+	return nil
+}
+
+func (r *Round) Chi(seat int, t time.Time, tile1, tile2 Tile) error {
+	if err := r.canChi(seat, t, tile1, tile2); err != nil {
+		return newActionError(seat, ActionChi, err)
+	}
+	seq := []Tile{r.lastDiscard(), tile1, tile2}
 	sort.Slice(seq, func(i, j int) bool {
 		return seq[i] < seq[j]
 	})
-	hand.Revealed = append(hand.Revealed, Meld{
-		Type:  MeldChi,
-		Tiles: seq,
-	})
-	r.Phase = PhaseDiscard
-	r.Events = append(r.Events, newEvent(EventChi, seat, t, seq...))
+	r.emit(newEvent(EventChi, seat, t, seq...))
 	r.LastActionTime = t
 	return nil
 }
 
-func (r *Round) Pong(seat int, t time.Time) error {
+func (r *Round) canPong(seat int) error {
 	if r.Finished {
-		return errors.New("round finished")
+		return ErrRoundFinished
 	}
 	if seat == r.previousTurn() {
-		return errors.New("wrong turn")
+		return ErrWrongTurn
 	}
 	if r.Phase != PhaseDraw {
-		return errors.New("wrong phase")
+		return ErrWrongPhase
 	}
 	if len(r.Discards) == 0 {
-		return errors.New("no discards")
+		return ErrNoDiscards
 	}
 	hand := &r.Hands[seat]
 	if hand.Concealed.Count(r.lastDiscard()) < 2 {
-		return errors.New("missing tiles")
+		return ErrMissingTiles
 	}
-	tile := r.popLastDiscard()
-	hand.Concealed.RemoveN(tile, 2)
-	hand.Revealed = append(hand.Revealed, Meld{
-		Type:  MeldPong,
-		Tiles: []Tile{tile},
-	})
-	r.Events = append(r.Events, newEvent(EventPong, seat, t, tile))
-	r.Turn = seat
-	r.Phase = PhaseDiscard
+	return nil
+}
+
+func (r *Round) Pong(seat int, t time.Time) error {
+	if err := r.canPong(seat); err != nil {
+		return newActionError(seat, ActionPong, err)
+	}
+	r.emit(newEvent(EventPong, seat, t, r.lastDiscard()))
 	r.LastActionTime = t
 	return nil
 }
 
-func (r *Round) GangFromDiscard(seat int, t time.Time) error {
+func (r *Round) canGangFromDiscard(seat int) error {
 	if r.Finished {
-		return errors.New("round finished")
+		return ErrRoundFinished
 	}
 	if seat == r.previousTurn() {
-		return errors.New("wrong turn")
+		return ErrWrongTurn
 	}
 	if r.Phase != PhaseDraw {
-		return errors.New("wrong phase")
+		return ErrWrongPhase
 	}
 	if len(r.Discards) == 0 {
-		return errors.New("no discards")
+		return ErrNoDiscards
 	}
 	hand := &r.Hands[seat]
 	if hand.Concealed.Count(r.lastDiscard()) < 3 {
-		return errors.New("missing tiles")
+		return ErrMissingTiles
+	}
+	return nil
+}
+
+func (r *Round) GangFromDiscard(seat int, t time.Time) error {
+	if err := r.canGangFromDiscard(seat); err != nil {
+		return newActionError(seat, ActionGang, err)
+	}
+	r.emit(newEvent(EventGang, seat, t, r.lastDiscard()))
+	if r.fourKongs() {
+		r.abort(seat, t, ReasonFourKongs)
+	} else {
+		r.replaceTile(seat, t)
 	}
-	tile := r.popLastDiscard()
-	hand.Concealed.RemoveN(tile, 3)
-	hand.Revealed = append(hand.Revealed, Meld{
-		Type:  MeldGang,
-		Tiles: []Tile{tile},
-	})
-	r.replaceTile(seat, t)
-	r.Events = append(r.Events, newEvent(EventGang, seat, t, tile))
-	r.Turn = seat
-	r.Phase = PhaseDiscard
 	r.LastActionTime = t
 	return nil
 }
 
-func (r *Round) GangFromHand(seat int, t time.Time, tile Tile) error {
+func (r *Round) canGangFromHand(seat int, tile Tile) error {
 	if r.Finished {
-		return errors.New("round finished")
+		return ErrRoundFinished
 	}
 	if seat != r.Turn {
-		return errors.New("wrong turn")
+		return ErrWrongTurn
 	}
 	if r.Phase != PhaseDiscard {
-		return errors.New("wrong phase")
+		return ErrWrongPhase
 	}
 	hand := &r.Hands[seat]
 	if hand.Concealed.Count(tile) > 3 {
-		hand.Concealed.RemoveN(tile, 4)
-		hand.Revealed = append(hand.Revealed, Meld{
-			Type:  MeldGang,
-			Tiles: []Tile{tile},
-		})
-		r.replaceTile(seat, t)
-		r.Events = append(r.Events, newEvent(EventGang, seat, t, tile))
-		r.LastActionTime = t
 		return nil
 	}
-	for i, meld := range hand.Revealed {
+	for _, meld := range hand.Revealed {
 		if meld.Type == MeldPong && meld.Tiles[0] == tile && hand.Concealed.Count(tile) > 0 {
-			hand.Concealed.Remove(tile)
-			hand.Revealed[i].Type = MeldGang
-			r.replaceTile(seat, t)
-			r.Events = append(r.Events, newEvent(EventGang, seat, t, tile))
-			r.LastActionTime = t
 			return nil
 		}
 	}
-	return errors.New("missing tiles")
+	return ErrMissingTiles
+}
+
+func (r *Round) GangFromHand(seat int, t time.Time, tile Tile) error {
+	if err := r.canGangFromHand(seat, tile); err != nil {
+		return newActionError(seat, ActionGang, err)
+	}
+	r.emit(newEvent(EventGang, seat, t, tile))
+	if r.fourKongs() {
+		r.abort(seat, t, ReasonFourKongs)
+	} else {
+		r.replaceTile(seat, t)
+	}
+	r.LastActionTime = t
+	return nil
 }
 
 func bestHand(winningHands []Melds, round *Round, seat int) (Melds, int) {
@@ -318,17 +369,17 @@ func removeTile(tiles []Tile, tile Tile) []Tile {
 
 func (r *Round) tsumo(seat int) (best Melds, points int, err error) {
 	if r.Finished {
-		err = errors.New("already won")
+		err = ErrAlreadyWon
 		return
 	}
 	winningHands := search(r.Hands[seat].Concealed)
 	if len(winningHands) == 0 {
-		err = errors.New("missing tiles")
+		err = ErrMissingTiles
 		return
 	}
 	best, points = bestHand(winningHands, r, seat)
 	if points == 0 {
-		err = errors.New("no tai")
+		err = ErrNoTai
 		return
 	}
 	return
@@ -338,13 +389,13 @@ func (r *Round) ron(seat int, t time.Time) (best Melds, points, loser int, err e
 	loser = r.previousTurn()
 	if r.Finished {
 		if t.After(r.LastActionTime.Add(r.ReservedDuration)) {
-			err = errors.New("too late")
+			err = ErrTooLate
 			return
 		}
 		winnerPrecedence := (r.Result.Winner - loser + 3) % 4
 		precedence := (seat - loser + 3) % 4
 		if precedence >= winnerPrecedence {
-			err = errors.New("no precedence")
+			err = ErrNoPrecedence
 			return
 		}
 	}
@@ -354,138 +405,221 @@ func (r *Round) ron(seat int, t time.Time) (best Melds, points, loser int, err e
 	} else {
 		winningTile = r.lastDiscard()
 	}
+	if r.Rules.SacredDiscard && contains(r.Passed[seat], winningTile) {
+		err = ErrSacredDiscard
+		return
+	}
 	winningHands := search(r.Hands[seat].Concealed, winningTile)
 	if len(winningHands) == 0 {
-		err = errors.New("missing tiles")
+		err = ErrMissingTiles
 		return
 	}
 	best, points = bestHand(winningHands, r, seat)
 	if points == 0 {
-		err = errors.New("no tai")
+		err = ErrNoTai
 		return
 	}
-	if !r.Finished {
-		// take the winning tile from the discard pile
-		r.WinningTile = r.popLastDiscard()
-	} else {
-		// take it from the previous winner
-		r.Hands[r.Result.Winner].Finished = removeTile(r.Hands[r.Result.Winner].Finished, r.WinningTile)
-	}
 	return
 }
 
-func (r *Round) Hu(seat int, t time.Time) error {
+// hu returns the best winning hand for a player, how many points it is worth
+// and who threw the winning tile, or an error if the player cannot win.
+func (r *Round) hu(seat int, t time.Time) (best Melds, points, loser int, err error) {
+	if r.Hands[seat].Dead {
+		err = ErrDeadHand
+		return
+	}
 	if seat == r.previousTurn() {
-		return errors.New("wrong turn")
+		err = ErrWrongTurn
+		return
 	}
 	if r.Turn != seat && r.Phase == PhaseDiscard {
-		return errors.New("wrong turn")
+		err = ErrWrongTurn
+		return
 	}
-	var best Melds
-	var points, loser int
-	var err error
 	if r.Phase == PhaseDiscard {
 		loser = -1
 		best, points, err = r.tsumo(seat)
 	} else {
 		best, points, loser, err = r.ron(seat, t)
 	}
-	if err != nil {
-		return err
+	if err == nil && seat == r.Dealer {
+		points += r.Rules.StreakPoints * r.Streak
 	}
-	r.Hands[seat].Concealed = TileBag{}
-	r.Hands[seat].Finished = best.Tiles()
-	// undo previous score distribution if someone won previously
-	if r.Result != nil {
-		for i, delta := range winnings(r.Rules, r.Result.Winner, r.Result.Loser, r.Result.Points) {
-			r.Scores[i] -= delta
-		}
+	return
+}
+
+// Hu declares a win. If the rules penalise false wins, declaring a win without
+// a winning hand or without enough points is recorded as a false win instead
+// of failing.
+func (r *Round) Hu(seat int, t time.Time) error {
+	best, points, loser, err := r.hu(seat, t)
+	if r.isFalseWin(err) {
+		r.falseWin(seat, t)
+		return nil
 	}
-	r.Result = &Result{
+	if err != nil {
+		return newActionError(seat, ActionHu, err)
+	}
+	previous := r.Result
+	result := &Result{
 		Dealer:       r.Dealer,
 		Wind:         r.Wind,
+		Streak:       r.Streak,
 		Winner:       seat,
 		WinningTiles: winningTiles(r.Hands[seat].Flowers, r.Hands[seat].Revealed, best),
 		Loser:        loser,
 		Points:       points,
+		Reason:       ReasonWin,
+		Tai:          tai(r, seat, append(append(Melds(nil), r.Hands[seat].Revealed...), best...)),
 	}
-	r.LastActionTime = t
-	r.Events = append(r.Events, newEvent(EventHu, seat, t))
-	for i, delta := range winnings(r.Rules, seat, loser, points) {
-		r.Scores[i] += delta
+	if seat == r.Dealer && r.Rules.StreakPoints*r.Streak > 0 {
+		result.Tai = append(result.Tai, Tai{TaiStreak, r.Rules.StreakPoints * r.Streak})
 	}
-	r.Finished = true
+	if r.Rules.Liability {
+		if liable := liablePlayer(r.Hands[seat].Revealed); liable != -1 {
+			result.Liable = &liable
+		}
+	}
+	r.emit(newEvent(EventHu, seat, t, best.Tiles()...))
+	// undo previous score distribution if someone won previously
+	if previous != nil {
+		var deltas [4]int
+		for i, delta := range winnings(r.Rules, previous.Winner, previous.Loser, previous.liable(), previous.Points) {
+			deltas[i] = -delta
+		}
+		r.emit(newPayoutEvent(previous.Winner, t, deltas))
+	}
+	r.emit(
+		newPayoutEvent(seat, t, winnings(r.Rules, seat, loser, result.liable(), points)),
+		Event{
+			Type:   EventResult,
+			Seat:   seat,
+			Time:   timeInMillis(t),
+			Result: result,
+		},
+	)
+	r.LastActionTime = t
 	return nil
 }
 
-func (r *Round) distributeTiles() {
-	r.Hands = [4]Hand{
-		{
-			Flowers:   []Tile{},
-			Revealed:  []Meld{},
-			Concealed: TileBag{},
-		},
-		{
-			Flowers:   []Tile{},
-			Revealed:  []Meld{},
-			Concealed: TileBag{},
-		},
-		{
-			Flowers:   []Tile{},
-			Revealed:  []Meld{},
-			Concealed: TileBag{},
-		},
-		{
+// passDiscard records the last discard as passed by every player who could
+// have won with it, once it is drawn past or claimed. The player moving on to
+// take a tile is then no longer bound by the tiles they passed.
+func (r *Round) passDiscard(seat int) {
+	if !r.Rules.SacredDiscard {
+		return
+	}
+	if tile := r.lastDiscard(); tile != "" {
+		discarder := r.previousTurn()
+		for i, hand := range r.Hands {
+			if i == discarder || contains(r.Passed[i], tile) {
+				continue
+			}
+			if len(search(hand.Concealed, tile)) > 0 {
+				r.Passed[i] = append(r.Passed[i], tile)
+			}
+		}
+	}
+	r.Passed[seat] = nil
+}
+
+// isFalseWin reports whether a failed declaration of a win is penalised as a
+// false win. Declarations which fail because of when they were made are not.
+func (r *Round) isFalseWin(err error) bool {
+	if r.Rules.FalseWin == FalseWinAllowed || r.Finished {
+		return false
+	}
+	return errors.Is(err, ErrMissingTiles) || errors.Is(err, ErrNoTai)
+}
+
+// falseWin penalises a player for declaring a win without a winning hand.
+func (r *Round) falseWin(seat int, t time.Time) {
+	e := newEvent(EventFalseWin, seat, t)
+	if r.Rules.FalseWin == FalseWinExposed {
+		for tile, count := range r.Hands[seat].Concealed {
+			for i := 0; i < count; i++ {
+				e.Tiles = append(e.Tiles, tile)
+			}
+		}
+		sort.Slice(e.Tiles, func(i, j int) bool {
+			return e.Tiles[i] < e.Tiles[j]
+		})
+	}
+	var deltas [4]int
+	for i := range deltas {
+		if i != seat {
+			deltas[i] += r.Rules.FalseWinPenalty
+			deltas[seat] -= r.Rules.FalseWinPenalty
+		}
+	}
+	r.emit(e, newPayoutEvent(seat, t, deltas))
+	r.LastActionTime = t
+}
+
+func newHands() [4]Hand {
+	var hands [4]Hand
+	for i := range hands {
+		hands[i] = Hand{
 			Flowers:   []Tile{},
 			Revealed:  []Meld{},
 			Concealed: TileBag{},
-		},
+		}
 	}
+	return hands
+}
+
+func (r *Round) deal(seat int, t time.Time, n int) {
+	tiles := make([]Tile, n)
+	copy(tiles, r.Wall)
+	r.emit(newPrivateEvent(EventDeal, seat, t, tiles...))
+}
+
+func (r *Round) distributeTiles(t time.Time) {
 	order := []int{r.Dealer, (r.Dealer + 1) % 4, (r.Dealer + 2) % 4, (r.Dealer + 3) % 4}
 	// draw 4 tiles 3 times
 	for i := 0; i < 3; i++ {
-		var draws []Tile
 		for _, seat := range order {
-			draws = r.drawFrontN(4)
-			r.Hands[seat].Concealed.Add(draws...)
+			r.deal(seat, t, 4)
 		}
 	}
 	// draw one tile
-	var draw Tile
 	for _, seat := range order {
-		draw = r.drawFront()
-		r.Hands[seat].Concealed.Add(draw)
+		r.deal(seat, t, 1)
 	}
 	// dealer draws one extra tile
-	draw = r.drawFront()
-	r.Hands[r.Dealer].Concealed.Add(draw)
+	r.deal(r.Dealer, t, 1)
 	// replace flowers
+	if !r.Rules.Tiles.hasFlowers() {
+		return
+	}
 	for len(order) > 0 {
 		seat := order[0]
 		order = order[1:]
 		mustReplaceAgain := false
-		var flowers, replacements []Tile
+		var flowers []Tile
 		for tile := range r.Hands[seat].Concealed {
-			if isFlower(tile) {
-				draw = r.drawBack()
-				if isFlower(draw) {
-					mustReplaceAgain = true
-				}
+			if r.Rules.Tiles.isFlower(tile) {
 				flowers = append(flowers, tile)
-				replacements = append(replacements, draw)
 			}
 		}
-		if mustReplaceAgain {
-			order = append(order, seat)
-		}
 		sort.Slice(flowers, func(i, j int) bool {
 			return flowers[i] < flowers[j]
 		})
-		r.Hands[seat].Flowers = append(r.Hands[seat].Flowers, flowers...)
-		r.Hands[seat].Concealed.Remove(flowers...)
-		r.Hands[seat].Concealed.Add(replacements...)
+		for _, flower := range flowers {
+			draw := r.nextReplacement()
+			if r.Rules.Tiles.isFlower(draw) {
+				mustReplaceAgain = true
+			}
+			r.emit(
+				newEvent(EventFlower, seat, t, flower),
+				newPrivateEvent(EventReplace, seat, t, draw),
+			)
+		}
+		if mustReplaceAgain {
+			order = append(order, seat)
+		}
 	}
-	return
 }
 
 func contains(tiles []Tile, tile Tile) bool {
@@ -507,52 +641,137 @@ func containsAll(haystack []Tile, needles []Tile) bool {
 }
 
 func (r *Round) addFlower(seat int, t time.Time, flower Tile) {
-	r.Events = append(r.Events, Event{
-		Type:  EventFlower,
-		Seat:  seat,
-		Time:  timeInMillis(t),
-		Tiles: []Tile{flower},
-	})
-	r.Hands[seat].Flowers = append(r.Hands[seat].Flowers, flower)
-	if groups, ok := bites[flower]; ok {
-		for _, group := range groups {
-			if containsAll(r.Hands[seat].Flowers, group.Flowers) {
-				r.Events = append(r.Events, Event{
-					Type:  EventBitten,
-					Seat:  seat,
-					Time:  timeInMillis(t),
-					Tiles: group.Flowers,
-				})
-				for i := range r.Scores {
-					if i != seat {
-						r.Scores[i] -= group.Payout
-					}
+	r.emit(newEvent(EventFlower, seat, t, flower))
+	for _, group := range r.Rules.Tiles.bites(flower) {
+		if containsAll(r.Hands[seat].Flowers, group.Flowers) {
+			var deltas [4]int
+			for i := range deltas {
+				if i != seat {
+					deltas[i] -= group.Payout
 				}
-				r.Scores[seat] += 3 * group.Payout
 			}
+			deltas[seat] += 3 * group.Payout
+			r.emit(
+				newEvent(EventBitten, seat, t, group.Flowers...),
+				newPayoutEvent(seat, t, deltas),
+			)
 		}
 	}
 }
 
+// Start starts a round by shuffling the wall, rolling the dice to break it and
+// dealing tiles to each player from the break. The tile set in the rules must
+// be valid.
 func (r *Round) Start(seed int64, t time.Time) {
-	r.Wall = newWall(rand.New(rand.NewSource(seed)))
-	r.distributeTiles()
-	r.Turn = r.Dealer
-	r.Phase = PhaseDiscard
-	r.Discards = []Tile{}
+	scores := r.Scores
+	rng := rand.New(rand.NewSource(seed))
+	r.Events = nil
+	r.emit(
+		Event{
+			Type:       EventStart,
+			Seat:       r.Dealer,
+			Time:       timeInMillis(t),
+			Tiles:      newWall(rng, r.Rules.Tiles),
+			Visibility: VisibilityHidden,
+			Wind:       r.Wind,
+			Streak:     r.Streak,
+			Scores:     &scores,
+		},
+		Event{
+			Type: EventDice,
+			Seat: r.Dealer,
+			Time: timeInMillis(t),
+			Dice: rollDice(rng),
+		},
+	)
+	r.distributeTiles(t)
+	r.checkNineTerminals(t)
 	r.LastActionTime = t
-	r.Events = []Event{newEvent(EventStart, 0, t)}
 }
 
-// Next returns a new round, setting the dealer and the prevailing wind
-// depending on the outcome of this round.
+// Replay discards the current state of a round and rebuilds it by applying
+// events in order, skipping actions which were undone. Rules and
+// ReservedDuration are not recorded in events and are kept.
+func (r *Round) Replay(events []Event) error {
+	if len(events) == 0 || events[0].Type != EventStart {
+		return errors.New("missing start event")
+	}
+	*r = Round{
+		Rules:            r.Rules,
+		ReservedDuration: r.ReservedDuration,
+	}
+	for _, e := range withoutUndone(events) {
+		r.apply(e)
+	}
+	r.Events = append([]Event(nil), events...)
+	r.LastActionTime = timeFromMillis(events[len(events)-1].Time)
+	return nil
+}
+
+// roundRecord is how a round is persisted. Apart from its settings, a round is
+// stored as its event log and rebuilt by replaying it.
+type roundRecord struct {
+	Rules            Rules         `json:"rules"`
+	ReservedDuration time.Duration `json:"reserved_duration"`
+	Events           []Event       `json:"events"`
+}
+
+// snapshot is how rounds saved before events were recorded in full are
+// stored. It has the fields of a Round without its JSON methods.
+type snapshot Round
+
+// recordedInFull reports whether events start with a start event holding the
+// tiles dealt, so that a round can be rebuilt from them.
+func recordedInFull(events []Event) bool {
+	return len(events) > 0 && events[0].Type == EventStart && len(events[0].Tiles) > 0
+}
+
+func (r Round) MarshalJSON() ([]byte, error) {
+	if !recordedInFull(r.Events) {
+		// a round loaded from a snapshot cannot be rebuilt from its events
+		return json.Marshal(snapshot(r))
+	}
+	return json.Marshal(roundRecord{
+		Rules:            r.Rules,
+		ReservedDuration: r.ReservedDuration,
+		Events:           r.Events,
+	})
+}
+
+func (r *Round) UnmarshalJSON(data []byte) error {
+	var record roundRecord
+	err := json.Unmarshal(data, &record)
+	if err != nil {
+		return err
+	}
+	if !recordedInFull(record.Events) {
+		err = json.Unmarshal(data, (*snapshot)(r))
+		if err != nil {
+			return err
+		}
+		if r.DeadWall == nil {
+			r.splitWall(r.Wall, DeadWallSize)
+		}
+		return nil
+	}
+	r.Rules = record.Rules
+	r.ReservedDuration = record.ReservedDuration
+	return r.Replay(record.Events)
+}
+
+// Next returns a new round, setting the dealer, their streak and the
+// prevailing wind depending on the outcome of this round. The dealer is kept
+// if they won, or if the round was drawn and the rules keep the dealer on a
+// draw.
 func (r *Round) Next() (*Round, error) {
 	if !r.Finished {
-		return nil, errors.New("unfinished")
+		return nil, ErrUnfinished
 	}
 	dealer := r.Dealer
 	wind := r.Wind
-	if r.Result.Winner != dealer {
+	streak := r.Streak + 1
+	keep := r.Result.Winner == dealer || r.Result.Winner == -1 && r.Rules.DealerKeepsOnDraw
+	if !keep {
 		if dealer == 3 && wind == DirectionNorth {
 			return nil, ErrNoMoreRounds
 		}
@@ -560,42 +779,138 @@ func (r *Round) Next() (*Round, error) {
 		if dealer == 0 {
 			wind++
 		}
+		streak = 0
 	}
 	return &Round{
 		Scores:           r.Scores,
 		Dealer:           dealer,
+		Streak:           streak,
 		Wind:             wind,
 		Rules:            r.Rules,
 		ReservedDuration: r.ReservedDuration,
 	}, nil
 }
 
+func (r *Round) canEnd(seat int) error {
+	if r.Finished {
+		return ErrRoundFinished
+	}
+	if r.Turn != seat {
+		return ErrWrongTurn
+	}
+	if r.Phase != PhaseDiscard {
+		return ErrWrongPhase
+	}
+	if len(r.Wall) > 0 {
+		return ErrDrawsRemaining
+	}
+	return nil
+}
+
 // End ends a round in a draw. Only the player who drew the last available tile
 // from the wall may initiate this action.
 func (r *Round) End(seat int, t time.Time) error {
-	if r.Turn != seat {
-		return errors.New("wrong turn")
+	if err := r.canEnd(seat); err != nil {
+		return newActionError(seat, ActionEnd, err)
+	}
+	r.emit(
+		newEvent(EventEnd, seat, t),
+		Event{
+			Type: EventResult,
+			Seat: seat,
+			Time: timeInMillis(t),
+			Result: &Result{
+				Dealer: r.Dealer,
+				Wind:   r.Wind,
+				Streak: r.Streak,
+				Winner: -1,
+				Loser:  -1,
+				Reason: ReasonWallExhausted,
+			},
+		},
+	)
+	r.LastActionTime = t
+	return nil
+}
+
+// CanUndo returns an error if a player may not undo their most recent action
+// at a certain time. An action can only be undone if nobody else has acted
+// since and its reserved duration is not yet over. Actions which drew tiles
+// from the wall cannot be undone, since the player would have seen them.
+func (r *Round) CanUndo(seat int, t time.Time) error {
+	action := r.lastAction
+	if action == nil || action.Seat != seat || action.Type == EventFalseWin {
+		// false wins cannot be taken back
+		return ErrNothingToUndo
 	}
-	if r.Phase != PhaseDiscard {
-		return errors.New("wrong phase")
+	if r.lastActionDrew {
+		return ErrUndoDraw
 	}
-	if len(r.Wall) >= MinTilesLeft {
-		return errors.New("some draws remaining")
+	if !t.Before(timeFromMillis(action.Time).Add(r.ReservedDuration)) {
+		return ErrTooLate
 	}
-	r.Finished = true
-	r.Result = &Result{
-		Dealer: r.Dealer,
-		Wind:   r.Wind,
-		Winner: -1,
-		Loser:  -1,
+	return nil
+}
+
+// Undo reverses a player's most recent action, including any score changes
+// resulting from it. The undo is recorded as an event and the round is
+// rebuilt from its event log.
+func (r *Round) Undo(seat int, t time.Time) error {
+	if err := r.CanUndo(seat, t); err != nil {
+		return newActionError(seat, ActionUndo, err)
+	}
+	events := append(r.Events, newEvent(EventUndo, seat, t))
+	if err := r.Replay(events); err != nil {
+		return err
 	}
 	r.LastActionTime = t
-	r.Events = append(r.Events, newEvent(EventEnd, seat, t))
 	return nil
 }
 
+// unseen returns how many copies of each kind of non-flower tile in play are
+// not visible from a certain seat: in their own hand, in the discards, in
+// revealed melds, in finished hands or in hands exposed after a false win.
+func (r *Round) unseen(seat int) map[Tile]int {
+	counts := make(map[Tile]int)
+	for _, tile := range suitedTiles {
+		if r.Rules.Tiles.inPlay(tile) {
+			counts[tile] = 4
+		}
+	}
+	if r.Rules.Tiles.Jokers > 0 {
+		counts[TileJoker] = r.Rules.Tiles.Jokers
+	}
+	see := func(tile Tile, n int) {
+		if counts[tile] > n {
+			counts[tile] -= n
+		} else if counts[tile] > 0 {
+			counts[tile] = 0
+		}
+	}
+	for _, tile := range r.Discards {
+		see(tile, 1)
+	}
+	for i, hand := range r.Hands {
+		for _, tile := range hand.Revealed.Tiles() {
+			see(tile, 1)
+		}
+		for _, tile := range hand.Finished {
+			see(tile, 1)
+		}
+		if i == seat || hand.Exposed {
+			for tile, count := range hand.Concealed {
+				see(tile, count)
+			}
+		}
+	}
+	return counts
+}
+
 // View returns a view of a round from a certain seat. Values of seat outside
-// of [0, 3] will return a bystander's view of the round.
+// of [0, 3] will return a bystander's view of the round. Events are redacted
+// according to their visibility. The legal actions for the seat are those
+// available once the reserved duration after the last action is over, apart
+// from undo, which is included while it is still available.
 func (r *Round) View(seat int) RoundView {
 	var hands [4]Hand
 	for i, hand := range r.Hands {
@@ -605,32 +920,37 @@ func (r *Round) View(seat int) RoundView {
 			hands[i] = hand.View()
 		}
 	}
-	return RoundView{
+	events := make([]Event, len(r.Events))
+	for i, e := range r.Events {
+		events[i] = e.visibleTo(seat)
+	}
+	actions := r.LegalActions(seat, r.LastActionTime.Add(r.ReservedDuration))
+	if r.CanUndo(seat, r.LastActionTime) == nil {
+		actions = append(actions, Action{Type: ActionUndo})
+	}
+	view := RoundView{
 		Seat:             seat,
 		Scores:           r.Scores,
 		Hands:            hands,
-		DrawsLeft:        len(r.Wall) - MinTilesLeft + 1,
+		DrawsLeft:        len(r.Wall),
+		Dice:             r.Dice,
 		Discards:         r.Discards,
+		Rivers:           r.Rivers,
 		Wind:             r.Wind,
 		Dealer:           r.Dealer,
+		Streak:           r.Streak,
 		Turn:             r.Turn,
 		Phase:            r.Phase,
-		Events:           r.Events,
+		Events:           events,
 		Result:           r.Result,
 		LastActionTime:   r.LastActionTime.UnixNano() / 1e6,
 		ReservedDuration: r.ReservedDuration.Milliseconds(),
 		Finished:         r.Finished,
+		Actions:          actions,
+		Unseen:           r.unseen(seat),
 	}
-}
-
-func newWall(r *rand.Rand) []Tile {
-	var wall []Tile
-	wall = append(wall, flowerTiles...)
-	for _, tile := range suitedTiles {
-		wall = append(wall, tile, tile, tile, tile)
+	if 0 <= seat && seat <= 3 {
+		view.SacredDiscards = r.Passed[seat]
 	}
-	r.Shuffle(len(wall), func(i, j int) {
-		wall[i], wall[j] = wall[j], wall[i]
-	})
-	return wall
+	return view
 }
@@ -1,11 +1,14 @@
 package mahjong
 
 import (
+	"encoding/json"
+	"errors"
 	"math/rand"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRound_Draw(t *testing.T) {
@@ -29,7 +32,7 @@ func TestRound_Draw(t *testing.T) {
 			ReservedDuration: 2 * time.Second,
 		}
 		err := r.Draw(0, time.Now())
-		assert.EqualError(t, err, "cannot draw during reserved duration")
+		assert.True(t, errors.Is(err, ErrReservedDuration))
 	})
 	t.Run("successful draw", func(t *testing.T) {
 		seat := 0
@@ -47,33 +50,56 @@ func TestRound_Draw(t *testing.T) {
 		assert.Equal(t, seat, r.Turn)
 		assert.Equal(t, PhaseDiscard, r.Phase)
 		assert.Equal(t, []Event{{
-			Type: EventDraw,
-			Seat: seat,
-			Time: timeInMillis(now),
+			Type:       EventDraw,
+			Seat:       seat,
+			Time:       timeInMillis(now),
+			Tiles:      []Tile{TileBamboo1},
+			Visibility: VisibilityOwner,
 		}}, r.Events)
 		assert.Equal(t, now, r.LastActionTime)
 	})
 	t.Run("successful draw with flowers", func(t *testing.T) {
 		seat := 0
 		r := &Round{
-			Wall:  []Tile{TileGentlemen1, TileBamboo1, TileDots5, TileGentlemen2},
-			Turn:  seat,
-			Phase: PhaseDraw,
-			Hands: [4]Hand{{Concealed: NewTileBag([]Tile{TileWindsWest})}},
+			Wall:     []Tile{TileGentlemen1, TileBamboo1},
+			DeadWall: []Tile{TileDots5, TileGentlemen2},
+			Turn:     seat,
+			Phase:    PhaseDraw,
+			Hands:    [4]Hand{{Concealed: NewTileBag([]Tile{TileWindsWest})}},
 		}
 		now := time.Now()
 		err := r.Draw(seat, now)
 		assert.NoError(t, err)
 		assert.Equal(t, []Tile{TileGentlemen1, TileGentlemen2}, r.Hands[seat].Flowers)
-		assert.Equal(t, []Tile{TileBamboo1}, r.Wall)
+		assert.Empty(t, r.Wall)
+		assert.Equal(t, []Tile{TileBamboo1}, r.DeadWall)
 		assert.Equal(t, NewTileBag([]Tile{TileWindsWest, TileDots5}), r.Hands[seat].Concealed)
 		assert.Equal(t, seat, r.Turn)
 		assert.Equal(t, PhaseDiscard, r.Phase)
-		assert.Contains(t, r.Events, Event{
-			Type: EventDraw,
-			Seat: seat,
-			Time: timeInMillis(now),
-		})
+		assert.Equal(t, []Event{
+			{Type: EventDraw, Seat: seat, Time: timeInMillis(now), Tiles: []Tile{TileGentlemen1}, Visibility: VisibilityOwner},
+			{Type: EventFlower, Seat: seat, Time: timeInMillis(now), Tiles: []Tile{TileGentlemen1}},
+			{Type: EventReplace, Seat: seat, Time: timeInMillis(now), Tiles: []Tile{TileGentlemen2}, Visibility: VisibilityOwner},
+			{Type: EventFlower, Seat: seat, Time: timeInMillis(now), Tiles: []Tile{TileGentlemen2}},
+			{Type: EventReplace, Seat: seat, Time: timeInMillis(now), Tiles: []Tile{TileDots5}, Visibility: VisibilityOwner},
+		}, r.Events)
+	})
+	t.Run("drawing a flower without a replacement tile draws the round", func(t *testing.T) {
+		seat := 0
+		r := &Round{
+			Wall:     []Tile{TileGentlemen1},
+			DeadWall: []Tile{TileGentlemen2},
+			Turn:     seat,
+			Phase:    PhaseDraw,
+			Hands:    [4]Hand{{Concealed: NewTileBag([]Tile{TileWindsWest})}},
+		}
+		err := r.Draw(seat, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, []Tile{TileGentlemen1, TileGentlemen2}, r.Hands[seat].Flowers)
+		assert.Empty(t, r.Wall)
+		assert.Empty(t, r.DeadWall)
+		assert.True(t, r.Finished)
+		assert.Equal(t, ReasonWallExhausted, r.Result.Reason)
 	})
 }
 
@@ -196,7 +222,7 @@ func TestRound_Chi(t *testing.T) {
 			ReservedDuration: 2 * time.Second,
 		}
 		err := r.Chi(0, time.Now(), TileBamboo1, TileBamboo2)
-		assert.EqualError(t, err, "cannot chi during reserved duration")
+		assert.True(t, errors.Is(err, ErrReservedDuration))
 	})
 	t.Run("cannot chi after round is finished", func(t *testing.T) {
 		r := &Round{
@@ -219,9 +245,11 @@ func TestRound_Chi(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, []Tile{TileBamboo4}, r.Discards)
 		assert.Equal(t, NewTileBag([]Tile{TileWindsWest}), r.Hands[0].Concealed)
+		feeder := 3
 		assert.Equal(t, Melds{{
-			Type:  MeldChi,
-			Tiles: []Tile{TileBamboo1, TileBamboo2, TileBamboo3},
+			Type:   MeldChi,
+			Tiles:  []Tile{TileBamboo1, TileBamboo2, TileBamboo3},
+			Feeder: &feeder,
 		}}, r.Hands[0].Revealed)
 		assert.Equal(t, 0, r.Turn)
 		assert.Equal(t, PhaseDiscard, r.Phase)
@@ -286,9 +314,11 @@ func TestRound_Pong(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, []Tile{TileDots1}, r.Discards)
 		assert.Equal(t, NewTileBag([]Tile{TileWindsWest}), r.Hands[seat].Concealed)
+		feeder := 2
 		assert.Equal(t, Melds{{
-			Type:  MeldPong,
-			Tiles: []Tile{TileDragonsRed},
+			Type:   MeldPong,
+			Tiles:  []Tile{TileDragonsRed},
+			Feeder: &feeder,
 		}}, r.Hands[seat].Revealed)
 		assert.Equal(t, seat, r.Turn)
 		assert.Equal(t, PhaseDiscard, r.Phase)
@@ -343,7 +373,8 @@ func TestRound_GangFromDiscard(t *testing.T) {
 	t.Run("successful gang from discard", func(t *testing.T) {
 		seat := 1
 		r := &Round{
-			Wall:     []Tile{TileCharacters4, TileCharacters6, TileGentlemen1},
+			Wall:     []Tile{TileCharacters4},
+			DeadWall: []Tile{TileCharacters6, TileGentlemen1},
 			Turn:     3,
 			Phase:    PhaseDraw,
 			Discards: []Tile{TileDots1, TileDragonsRed},
@@ -358,9 +389,11 @@ func TestRound_GangFromDiscard(t *testing.T) {
 		assert.Equal(t, []Tile{TileDots1}, r.Discards)
 		assert.Equal(t, []Tile{TileCat, TileGentlemen1}, r.Hands[seat].Flowers)
 		assert.Equal(t, NewTileBag([]Tile{TileWindsWest, TileCharacters6}), r.Hands[seat].Concealed)
+		feeder := 2
 		assert.Equal(t, Melds{{
-			Type:  MeldGang,
-			Tiles: []Tile{TileDragonsRed},
+			Type:   MeldGang,
+			Tiles:  []Tile{TileDragonsRed},
+			Feeder: &feeder,
 		}}, r.Hands[seat].Revealed)
 		assert.Equal(t, seat, r.Turn)
 		assert.Equal(t, PhaseDiscard, r.Phase)
@@ -406,9 +439,10 @@ func TestRound_GangFromHand(t *testing.T) {
 	t.Run("successful concealed gang", func(t *testing.T) {
 		seat := 0
 		r := &Round{
-			Wall:  []Tile{TileCharacters1, TileDots4, TileCat},
-			Turn:  0,
-			Phase: PhaseDiscard,
+			Wall:     []Tile{TileCharacters1},
+			DeadWall: []Tile{TileDots4, TileCat},
+			Turn:     0,
+			Phase:    PhaseDiscard,
 			Hands: [4]Hand{{
 				Flowers:   []Tile{TileSeasons1},
 				Concealed: TileBag{TileDragonsRed: 4},
@@ -422,7 +456,8 @@ func TestRound_GangFromHand(t *testing.T) {
 			Tiles: []Tile{TileDragonsRed},
 		}}, r.Hands[seat].Revealed)
 		assert.Equal(t, TileBag{TileDots4: 1}, r.Hands[seat].Concealed)
-		assert.Equal(t, []Tile{TileCharacters1}, r.Wall)
+		assert.Empty(t, r.Wall)
+		assert.Equal(t, []Tile{TileCharacters1}, r.DeadWall)
 		assert.Equal(t, seat, r.Turn)
 		assert.Equal(t, PhaseDiscard, r.Phase)
 		assert.Contains(t, r.Events, Event{
@@ -433,12 +468,30 @@ func TestRound_GangFromHand(t *testing.T) {
 		})
 		assert.Equal(t, now, r.LastActionTime)
 	})
+	t.Run("round is drawn when there is no replacement tile", func(t *testing.T) {
+		r := &Round{
+			DeadWall: []Tile{TileCat},
+			Turn:     0,
+			Phase:    PhaseDiscard,
+			Hands: [4]Hand{{
+				Concealed: TileBag{TileDragonsRed: 4, TileDots1: 1},
+			}},
+		}
+		err := r.GangFromHand(0, time.Now(), TileDragonsRed)
+		assert.NoError(t, err)
+		assert.Empty(t, r.DeadWall)
+		assert.Equal(t, []Tile{TileCat}, r.Hands[0].Flowers)
+		assert.True(t, r.Finished)
+		assert.Equal(t, -1, r.Result.Winner)
+		assert.Equal(t, ReasonWallExhausted, r.Result.Reason)
+	})
 	t.Run("successful promote pong to gang", func(t *testing.T) {
 		seat := 0
 		r := &Round{
-			Wall:  []Tile{TileCharacters1, TileDots4, TileCat},
-			Turn:  0,
-			Phase: PhaseDiscard,
+			Wall:     []Tile{TileCharacters1},
+			DeadWall: []Tile{TileDots4, TileCat},
+			Turn:     0,
+			Phase:    PhaseDiscard,
 			Hands: [4]Hand{{
 				Flowers: []Tile{TileSeasons1},
 				Revealed: []Meld{{
@@ -456,7 +509,8 @@ func TestRound_GangFromHand(t *testing.T) {
 			Tiles: []Tile{TileDragonsRed},
 		}}, r.Hands[seat].Revealed)
 		assert.Equal(t, TileBag{TileDots4: 1}, r.Hands[seat].Concealed)
-		assert.Equal(t, []Tile{TileCharacters1}, r.Wall)
+		assert.Empty(t, r.Wall)
+		assert.Equal(t, []Tile{TileCharacters1}, r.DeadWall)
 		assert.Equal(t, seat, r.Turn)
 		assert.Equal(t, PhaseDiscard, r.Phase)
 		assert.Contains(t, r.Events, Event{
@@ -555,18 +609,36 @@ func TestRound_Hu(t *testing.T) {
 			},
 			Loser:  -1,
 			Points: 1,
+			Reason: ReasonWin,
+			Tai:    []Tai{{TaiFlower, 1}},
 		}, r.Result)
 		assert.Equal(t, now, r.LastActionTime)
+		deltas := winnings(r.Rules, seat, -1, -1, 1)
 		assert.Equal(
 			t,
-			[]Event{{
-				Type: EventHu,
-				Seat: seat,
-				Time: timeInMillis(now),
-			}},
+			[]Event{
+				{
+					Type:  EventHu,
+					Seat:  seat,
+					Time:  timeInMillis(now),
+					Tiles: r.Hands[seat].Finished,
+				},
+				{
+					Type:   EventPayout,
+					Seat:   seat,
+					Time:   timeInMillis(now),
+					Scores: &deltas,
+				},
+				{
+					Type:   EventResult,
+					Seat:   seat,
+					Time:   timeInMillis(now),
+					Result: r.Result,
+				},
+			},
 			r.Events,
 		)
-		assert.Equal(t, winnings(r.Rules, seat, -1, 1), r.Scores)
+		assert.Equal(t, deltas, r.Scores)
 	})
 	t.Run("successful hu from discards", func(t *testing.T) {
 		seat := 2
@@ -603,6 +675,8 @@ func TestRound_Hu(t *testing.T) {
 			},
 			Loser:  3,
 			Points: 2,
+			Reason: ReasonWin,
+			Tai:    []Tai{{TaiFlower, 1}, {TaiSeatWind, 1}},
 		}, r.Result)
 	})
 	t.Run("cannot hu again after huing", func(t *testing.T) {
@@ -738,6 +812,8 @@ func TestRound_Hu(t *testing.T) {
 			},
 			Points: 2,
 			Loser:  3,
+			Reason: ReasonWin,
+			Tai:    []Tai{{TaiFlower, 1}, {TaiDragon, 1}},
 		}, r.Result)
 		assert.Equal(t, [4]int{-2, 8, -2, -4}, r.Scores)
 	})
@@ -754,6 +830,27 @@ func TestRound_View(t *testing.T) {
 	now := time.Unix(ms/1000, (ms%1000)*1e6)
 	r.Start(0, now)
 	_ = r.Discard(1, now, TileBamboo1)
+	// the wall is hidden from everyone, and dealt and drawn tiles are only
+	// visible to the player who received them
+	visibleEvents := func(seat int) []Event {
+		var events []Event
+		for _, e := range r.Events {
+			switch e.Type {
+			case EventStart:
+				assert.Equal(t, VisibilityHidden, e.Visibility)
+				e.Tiles = nil
+			case EventDeal, EventDraw, EventReplace:
+				assert.Equal(t, VisibilityOwner, e.Visibility)
+				if e.Seat != seat {
+					e.Tiles = nil
+				}
+			default:
+				assert.Equal(t, VisibilityPublic, e.Visibility)
+			}
+			events = append(events, e)
+		}
+		return events
+	}
 	t.Run("view from seat", func(t *testing.T) {
 		seat := 1
 		view := r.View(seat)
@@ -763,33 +860,25 @@ func TestRound_View(t *testing.T) {
 				Seat:   seat,
 				Scores: r.Scores,
 				Hands: [4]Hand{
-					{Flowers: []Tile{"07菊"}, Revealed: []Meld{}, Concealed: TileBag{"": 13}},
-					{Flowers: []Tile{}, Revealed: []Meld{}, Concealed: TileBag{"16四筒": 1, "27六索": 1, "29八索": 1, "34四万": 2, "35五万": 1, "36六万": 2, "38八万": 2, "43北风": 1, "44红中": 1, "46白板": 1}},
+					{Flowers: []Tile{"05梅"}, Revealed: []Meld{}, Concealed: TileBag{"": 13}},
+					{Flowers: []Tile{"10夏"}, Revealed: []Meld{}, Concealed: TileBag{"13一筒": 1, "15三筒": 1, "21九筒": 1, "25四索": 2, "26五索": 1, "28七索": 1, "31一万": 1, "36六万": 1, "37七万": 1, "40东风": 1, "46白板": 2}},
 					{Flowers: []Tile{}, Revealed: []Meld{}, Concealed: TileBag{"": 13}},
-					{Flowers: []Tile{"06兰", "12冬"}, Revealed: []Meld{}, Concealed: TileBag{"": 13}},
+					{Flowers: []Tile{"01猫", "04蜈蚣", "09春", "11秋", "12冬"}, Revealed: []Meld{}, Concealed: TileBag{"": 13}},
 				},
-				DrawsLeft: len(r.Wall) - 15,
-				Discards:  r.Discards,
-				Wind:      r.Wind,
-				Dealer:    r.Dealer,
-				Turn:      r.Turn,
-				Phase:     r.Phase,
-				Events: []Event{
-					{
-						Type: EventStart,
-						Time: ms,
-					},
-					{
-						Type:  EventDiscard,
-						Time:  ms,
-						Seat:  1,
-						Tiles: []Tile{TileBamboo1},
-					},
-				},
-
+				DrawsLeft:        len(r.Wall),
+				Dice:             r.Dice,
+				Discards:         r.Discards,
+				Rivers:           [4][]DiscardedTile{1: {{Tile: TileBamboo1}}},
+				Wind:             r.Wind,
+				Dealer:           r.Dealer,
+				Turn:             r.Turn,
+				Phase:            r.Phase,
+				Events:           visibleEvents(seat),
 				Result:           r.Result,
 				LastActionTime:   ms,
 				ReservedDuration: r.ReservedDuration.Milliseconds(),
+				Actions:          []Action{{Type: ActionUndo}},
+				Unseen:           r.unseen(seat),
 			},
 			view,
 		)
@@ -802,32 +891,24 @@ func TestRound_View(t *testing.T) {
 				Seat:   -1,
 				Scores: r.Scores,
 				Hands: [4]Hand{
-					{Flowers: []Tile{"07菊"}, Revealed: []Meld{}, Concealed: TileBag{"": 13}},
-					{Flowers: []Tile{}, Revealed: []Meld{}, Concealed: TileBag{"": 13}},
+					{Flowers: []Tile{"05梅"}, Revealed: []Meld{}, Concealed: TileBag{"": 13}},
+					{Flowers: []Tile{"10夏"}, Revealed: []Meld{}, Concealed: TileBag{"": 13}},
 					{Flowers: []Tile{}, Revealed: []Meld{}, Concealed: TileBag{"": 13}},
-					{Flowers: []Tile{"06兰", "12冬"}, Revealed: []Meld{}, Concealed: TileBag{"": 13}},
-				},
-				DrawsLeft: len(r.Wall) - 15,
-				Discards:  r.Discards,
-				Wind:      r.Wind,
-				Dealer:    r.Dealer,
-				Turn:      r.Turn,
-				Phase:     r.Phase,
-				Events: []Event{
-					{
-						Type: EventStart,
-						Time: ms,
-					},
-					{
-						Type:  EventDiscard,
-						Time:  ms,
-						Seat:  1,
-						Tiles: []Tile{TileBamboo1},
-					},
+					{Flowers: []Tile{"01猫", "04蜈蚣", "09春", "11秋", "12冬"}, Revealed: []Meld{}, Concealed: TileBag{"": 13}},
 				},
+				DrawsLeft:        len(r.Wall),
+				Dice:             r.Dice,
+				Discards:         r.Discards,
+				Rivers:           [4][]DiscardedTile{1: {{Tile: TileBamboo1}}},
+				Wind:             r.Wind,
+				Dealer:           r.Dealer,
+				Turn:             r.Turn,
+				Phase:            r.Phase,
+				Events:           visibleEvents(-1),
 				Result:           r.Result,
 				LastActionTime:   ms,
 				ReservedDuration: r.ReservedDuration.Milliseconds(),
+				Unseen:           r.unseen(-1),
 			},
 			view,
 		)
@@ -836,7 +917,7 @@ func TestRound_View(t *testing.T) {
 
 func Test_newWall(t *testing.T) {
 	r := rand.New(rand.NewSource(0))
-	got := newWall(r)
+	got := newWall(r, TileSet{})
 	want := []Tile{"38八万", "35五万", "27六索", "44红中", "22一索", "34四万", "35五万", "20八筒", "37七万", "13一筒", "43北风", "26五索", "21九筒", "25四索", "42西风", "17五筒", "38八万", "36六万", "16四筒", "43北风", "20八筒", "22一索", "37七万", "25四索", "42西风", "30九索", "19七筒", "06兰", "27六索", "07菊", "40东风", "32二万", "29八索", "36六万", "34四万", "46白板", "32二万", "15三筒", "17五筒", "37七万", "42西风", "14二筒", "43北风", "20八筒", "28七索", "45青发", "17五筒", "36六万", "34四万", "14二筒", "12冬", "46白板", "22一索", "40东风", "37七万", "28七索", "29八索", "16四筒", "39九万", "13一筒", "24三索", "01猫", "27六索", "40东风", "41南风", "34四万", "24三索", "31一万", "31一万", "25四索", "13一筒", "26五索", "15三筒", "14二筒", "18六筒", "24三索", "11秋", "19七筒", "45青发", "41南风", "44红中", "39九万", "27六索", "26五索", "10夏", "15三筒", "21九筒", "36六万", "41南风", "33三万", "29八索", "23二索", "28七索", "04蜈蚣", "32二万", "38八万", "29八索", "05梅", "39九万", "21九筒", "46白板", "33三万", "09春", "32二万", "25四索", "30九索", "39九万", "23二索", "02老鼠", "24三索", "44红中", "28七索", "45青发", "18六筒", "31一万", "14二筒", "43北风", "13一筒", "45青发", "30九索", "18六筒", "22一索", "31一万", "16四筒", "17五筒", "26五索", "23二索", "21九筒", "35五万", "42西风", "03公鸡", "35五万", "18六筒", "30九索", "46白板", "38八万", "40东风", "19七筒", "15三筒", "41南风", "33三万", "16四筒", "20八筒", "23二索", "08竹", "33三万", "19七筒", "44红中"}
 	assert.Equal(t, want, got)
 }
@@ -875,7 +956,9 @@ func TestRound_distributeTiles(t *testing.T) {
 			"19七筒", "01猫", // 3 replaces two tiles and gets a third flower
 		},
 	}
-	r.distributeTiles()
+	r.emit(Event{Type: EventStart, Seat: r.Dealer, Tiles: r.Wall})
+	r.distributeTiles(time.Now())
+	assert.Len(t, r.DeadWall, DeadWallSize)
 	assert.Equal(t,
 		NewTileBag([]Tile{"38八万", "35五万", "27六索", "44红中", "38八万", "36六万", "16四筒", "43北风", "29八索", "36六万", "34四万", "46白板", "34四万", "22一索"}),
 		r.Hands[1].Concealed)
@@ -903,7 +986,7 @@ func TestRound_distributeTiles(t *testing.T) {
 	assert.ElementsMatch(t, []Tile{"07菊"}, r.Hands[0].Flowers)
 	assert.Equal(t,
 		[]Tile{"40东风", "37七万", "28七索", "29八索", "16四筒", "39九万", "13一筒", "24三索", "44红中", "27六索", "40东风", "41南风", "34四万", "24三索", "31一万", "31一万", "25四索", "13一筒", "26五索", "15三筒", "14二筒", "18六筒", "24三索", "11秋", "19七筒", "45青发", "41南风", "44红中", "39九万", "27六索", "26五索", "10夏", "15三筒", "21九筒", "36六万", "41南风", "33三万", "29八索", "23二索", "28七索", "04蜈蚣", "32二万", "38八万", "29八索", "05梅", "39九万", "21九筒", "46白板", "33三万", "09春", "32二万", "25四索", "30九索", "39九万", "23二索", "02老鼠", "24三索", "44红中", "28七索", "45青发", "18六筒", "31一万", "14二筒", "43北风", "13一筒", "45青发", "30九索", "18六筒", "22一索", "31一万", "16四筒", "17五筒", "26五索", "23二索", "21九筒", "35五万", "42西风", "03公鸡", "35五万", "18六筒", "30九索", "46白板", "38八万", "40东风", "19七筒", "15三筒", "41南风", "33三万", "16四筒", "20八筒"},
-		r.Wall)
+		append(r.Wall, r.DeadWall...))
 }
 
 func TestRound_Start(t *testing.T) {
@@ -912,7 +995,482 @@ func TestRound_Start(t *testing.T) {
 	r.Start(0, now)
 	assert.Equal(t, r.Dealer, r.Turn)
 	assert.Equal(t, r.Phase, PhaseDiscard)
-	assert.Equal(t, []Event{{Type: EventStart, Time: timeInMillis(now)}}, r.Events)
+	assert.Equal(t, EventStart, r.Events[0].Type)
+	assert.Equal(t, newWall(rand.New(rand.NewSource(0)), TileSet{}), r.Events[0].Tiles)
+	assert.Equal(t, EventDice, r.Events[1].Type)
+	assert.Len(t, r.Events[1].Dice, 3)
+	assert.Equal(t, r.Events[1].Dice, r.Dice)
+	assert.Len(t, r.DeadWall, DeadWallSize)
+	for _, e := range r.Events[2:] {
+		assert.Contains(t, []EventType{EventDeal, EventFlower, EventReplace, EventBitten, EventPayout}, e.Type)
+	}
+}
+
+func TestRound_Start_tileSets(t *testing.T) {
+	tests := []struct {
+		name string
+		set  TileSet
+	}{
+		{"no flowers", TileSet{NoFlowers: true}},
+		{"no animals", TileSet{NoAnimals: true}},
+		{"jokers", TileSet{Jokers: 4}},
+		{"one suit", TileSet{NoFlowers: true, NoAnimals: true, Suits: []Suit{SuitDots}}},
+		{"one suit with flowers", TileSet{Suits: []Suit{SuitDots}}},
+		{"no honours", TileSet{NoHonours: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, tt.set.Validate())
+			for seed := int64(0); seed < 20; seed++ {
+				r := &Round{Rules: Rules{Limit: 5, Tiles: tt.set}}
+				r.Start(seed, time.Unix(0, 0))
+				assert.NotEmpty(t, r.Wall, "seed %d", seed)
+				assert.Len(t, r.DeadWall, tt.set.deadWallSize(), "seed %d", seed)
+				assert.NoError(t, r.Validate(), "seed %d", seed)
+				players := [4]Player{&firstActionPlayer{}, &firstActionPlayer{}, &firstActionPlayer{}, &firstActionPlayer{}}
+				assert.NoError(t, PlayRound(r, players), "seed %d", seed)
+				assert.NoError(t, r.Validate(), "seed %d", seed)
+			}
+		})
+	}
+}
+
+func TestRound_Replay(t *testing.T) {
+	t.Run("requires a start event", func(t *testing.T) {
+		r := new(Round)
+		err := r.Replay([]Event{{Type: EventDraw}})
+		assert.EqualError(t, err, "missing start event")
+	})
+	t.Run("rebuilds state from events", func(t *testing.T) {
+		var ms int64 = 1598707747116
+		now := time.Unix(ms/1000, (ms%1000)*1e6)
+		r := &Round{
+			Scores:           [4]int{4, 2, 0, 1},
+			Dealer:           1,
+			Wind:             DirectionNorth,
+			ReservedDuration: 2 * time.Second,
+		}
+		r.Start(0, now)
+		_ = r.Discard(1, now, TileBamboo1)
+		_ = r.Draw(2, now.Add(2*time.Second))
+		replayed := &Round{ReservedDuration: r.ReservedDuration}
+		err := replayed.Replay(r.Events)
+		assert.NoError(t, err)
+		assert.Equal(t, r, replayed)
+	})
+}
+
+func TestRound_Undo(t *testing.T) {
+	var ms int64 = 1598707747116
+	now := time.Unix(ms/1000, (ms%1000)*1e6)
+	newRound := func() *Round {
+		r := &Round{
+			Scores:           [4]int{4, 2, 0, 1},
+			Dealer:           1,
+			Wind:             DirectionNorth,
+			ReservedDuration: 2 * time.Second,
+		}
+		r.Start(0, now)
+		return r
+	}
+	t.Run("nothing to undo before any actions", func(t *testing.T) {
+		r := newRound()
+		err := r.Undo(1, now)
+		assert.True(t, errors.Is(err, ErrNothingToUndo))
+	})
+	t.Run("cannot undo another player's action", func(t *testing.T) {
+		r := newRound()
+		_ = r.Discard(1, now, TileBamboo1)
+		err := r.Undo(2, now)
+		assert.True(t, errors.Is(err, ErrNothingToUndo))
+	})
+	t.Run("cannot undo after reserved duration", func(t *testing.T) {
+		r := newRound()
+		_ = r.Discard(1, now, TileBamboo1)
+		err := r.Undo(1, now.Add(2*time.Second))
+		assert.True(t, errors.Is(err, ErrTooLate))
+	})
+	t.Run("cannot undo draw", func(t *testing.T) {
+		r := newRound()
+		_ = r.Discard(1, now, TileBamboo1)
+		err := r.Draw(2, now.Add(3*time.Second))
+		assert.NoError(t, err)
+		err = r.Undo(2, now.Add(4*time.Second))
+		assert.True(t, errors.Is(err, ErrUndoDraw))
+		assert.Equal(t, PhaseDiscard, r.Phase)
+	})
+	t.Run("cannot undo gang with a replacement draw", func(t *testing.T) {
+		hand := []Tile{
+			TileDragonsRed, TileDragonsRed, TileDragonsRed, TileDragonsRed,
+			TileDragonsGreen, TileDragonsGreen, TileDragonsGreen,
+			TileDragonsWhite, TileDragonsWhite, TileDragonsWhite,
+			TileBamboo1, TileBamboo1, TileBamboo1,
+			TileDots5,
+		}
+		wall := append([]Tile(nil), hand...)
+		for i := 0; i < 20; i++ {
+			wall = append(wall, TileDots9)
+		}
+		r := &Round{ReservedDuration: 2 * time.Second}
+		err := r.Replay([]Event{
+			{Type: EventStart, Time: ms, Tiles: wall, Visibility: VisibilityHidden},
+			{Type: EventDeal, Time: ms, Tiles: hand, Visibility: VisibilityOwner},
+		})
+		assert.NoError(t, err)
+		err = r.GangFromHand(0, now, TileDragonsRed)
+		assert.NoError(t, err)
+		err = r.Undo(0, now.Add(time.Second))
+		assert.True(t, errors.Is(err, ErrUndoDraw))
+	})
+	t.Run("undo discard", func(t *testing.T) {
+		r := newRound()
+		before := &Round{ReservedDuration: r.ReservedDuration}
+		_ = before.Replay(r.Events)
+		_ = r.Discard(1, now, TileBamboo1)
+		err := r.Undo(1, now.Add(time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, before.Hands, r.Hands)
+		assert.Equal(t, before.Discards, r.Discards)
+		assert.Equal(t, 1, r.Turn)
+		assert.Equal(t, PhaseDiscard, r.Phase)
+		assert.Equal(t, Event{Type: EventUndo, Seat: 1, Time: ms + 1000}, r.Events[len(r.Events)-1])
+		assert.Equal(t, now.Add(time.Second), r.LastActionTime)
+	})
+	t.Run("undo hu reverses payouts", func(t *testing.T) {
+		hand := []Tile{
+			TileDragonsRed, TileDragonsRed, TileDragonsRed,
+			TileDragonsGreen, TileDragonsGreen, TileDragonsGreen,
+			TileDragonsWhite, TileDragonsWhite, TileDragonsWhite,
+			TileBamboo1, TileBamboo1, TileBamboo1,
+			TileDots5, TileDots5,
+		}
+		wall := append([]Tile(nil), hand...)
+		for i := 0; i < 20; i++ {
+			wall = append(wall, TileDots9)
+		}
+		r := &Round{ReservedDuration: 2 * time.Second}
+		scores := [4]int{4, 2, 0, 1}
+		err := r.Replay([]Event{
+			{Type: EventStart, Time: ms, Tiles: wall, Visibility: VisibilityHidden, Scores: &scores},
+			{Type: EventDeal, Time: ms, Tiles: hand, Visibility: VisibilityOwner},
+		})
+		assert.NoError(t, err)
+		err = r.Hu(0, now)
+		assert.NoError(t, err)
+		assert.True(t, r.Finished)
+		err = r.Undo(0, now.Add(time.Second))
+		assert.NoError(t, err)
+		assert.False(t, r.Finished)
+		assert.Nil(t, r.Result)
+		assert.Equal(t, scores, r.Scores)
+		assert.Equal(t, NewTileBag(hand), r.Hands[0].Concealed)
+	})
+	t.Run("undone actions are skipped when replaying", func(t *testing.T) {
+		r := newRound()
+		_ = r.Discard(1, now, TileBamboo1)
+		_ = r.Undo(1, now.Add(time.Second))
+		replayed := &Round{ReservedDuration: r.ReservedDuration}
+		err := replayed.Replay(r.Events)
+		assert.NoError(t, err)
+		assert.Equal(t, r, replayed)
+	})
+}
+
+func TestRound_Hu_streak(t *testing.T) {
+	hand := []Tile{
+		TileDragonsRed, TileDragonsRed, TileDragonsRed,
+		TileDragonsGreen, TileDragonsGreen, TileDragonsGreen,
+		TileDragonsWhite, TileDragonsWhite, TileDragonsWhite,
+		TileBamboo1, TileBamboo1, TileBamboo1,
+		TileDots5, TileDots5,
+	}
+	wall := append([]Tile(nil), hand...)
+	for i := 0; i < 20; i++ {
+		wall = append(wall, TileDots9)
+	}
+	points := func(streak int) int {
+		r := &Round{Rules: Rules{Limit: 10, StreakPoints: 1}}
+		_ = r.Replay([]Event{
+			{Type: EventStart, Tiles: wall, Visibility: VisibilityHidden, Streak: streak},
+			{Type: EventDeal, Tiles: hand, Visibility: VisibilityOwner},
+		})
+		err := r.Hu(0, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, streak, r.Result.Streak)
+		return r.Result.Points
+	}
+	assert.Equal(t, points(0)+2, points(2))
+}
+
+func TestRound_Hu_falseWin(t *testing.T) {
+	newRound := func(rule FalseWinRule) *Round {
+		return &Round{
+			Rules:            Rules{FalseWin: rule, FalseWinPenalty: 8},
+			ReservedDuration: time.Second,
+			Turn:             1,
+			Phase:            PhaseDiscard,
+			Hands: [4]Hand{{}, {
+				Concealed: TileBag{TileDots1: 2, TileDragonsRed: 1},
+			}},
+		}
+	}
+	t.Run("false win exposes hand", func(t *testing.T) {
+		r := newRound(FalseWinExposed)
+		now := time.Now()
+		err := r.Hu(1, now)
+		assert.NoError(t, err)
+		assert.False(t, r.Finished)
+		assert.Equal(t, [4]int{8, -24, 8, 8}, r.Scores)
+		assert.Equal(t, newEvent(EventFalseWin, 1, now, TileDots1, TileDots1, TileDragonsRed), r.Events[0])
+		assert.True(t, r.Hands[1].Exposed)
+		assert.Equal(t, TileBag{TileDots1: 2, TileDragonsRed: 1}, r.Hands[1].View().Concealed)
+		assert.Equal(t, ErrNothingToUndo, r.CanUndo(1, now))
+	})
+	t.Run("false win kills hand", func(t *testing.T) {
+		r := newRound(FalseWinDeadHand)
+		now := time.Now()
+		err := r.Hu(1, now)
+		assert.NoError(t, err)
+		assert.True(t, r.Hands[1].Dead)
+		assert.Equal(t, newEvent(EventFalseWin, 1, now), r.Events[0])
+		err = r.Hu(1, now)
+		assert.EqualError(t, err, "dead hand")
+		assert.Equal(t, [4]int{8, -24, 8, 8}, r.Scores)
+	})
+	t.Run("declaring out of turn is not a false win", func(t *testing.T) {
+		r := newRound(FalseWinExposed)
+		err := r.Hu(2, time.Now())
+		assert.EqualError(t, err, "wrong turn")
+		assert.Empty(t, r.Events)
+	})
+	t.Run("false wins allowed", func(t *testing.T) {
+		r := newRound(FalseWinAllowed)
+		err := r.Hu(1, time.Now())
+		assert.EqualError(t, err, "missing tiles")
+		assert.Empty(t, r.Events)
+	})
+}
+
+func TestRound_Hu_liability(t *testing.T) {
+	seat0, seat2 := 0, 2
+	r := &Round{
+		Rules: Rules{Limit: 10, Liability: true},
+		Turn:  1,
+		Phase: PhaseDiscard,
+		Hands: [4]Hand{{}, {
+			Flowers: []Tile{},
+			Revealed: Melds{
+				{Type: MeldPong, Tiles: []Tile{TileDragonsRed}, Feeder: &seat0},
+				{Type: MeldPong, Tiles: []Tile{TileDragonsGreen}, Feeder: &seat0},
+				{Type: MeldPong, Tiles: []Tile{TileDragonsWhite}, Feeder: &seat2},
+			},
+			Concealed: NewTileBag([]Tile{
+				TileBamboo1, TileBamboo2, TileBamboo3,
+				TileDots5, TileDots5,
+			}),
+		}},
+	}
+	err := r.Hu(1, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, &seat2, r.Result.Liable)
+	assert.Equal(t, 0, r.Scores[0])
+	assert.Equal(t, 0, r.Scores[3])
+	assert.Equal(t, -r.Scores[1], r.Scores[2])
+}
+
+func TestRound_Hu_sacredDiscard(t *testing.T) {
+	newRound := func(rules Rules) *Round {
+		return &Round{
+			Rules:    rules,
+			Wall:     []Tile{TileDots5, TileDots9},
+			Discards: []Tile{TileDragonsRed},
+			Turn:     1,
+			Phase:    PhaseDraw,
+			Hands: [4]Hand{
+				{Concealed: TileBag{}},
+				{Concealed: TileBag{TileDragonsRed: 1}},
+				{Concealed: NewTileBag([]Tile{
+					TileDragonsRed, TileDragonsRed,
+					TileDragonsGreen, TileDragonsGreen, TileDragonsGreen,
+					TileDots1, TileDots1,
+				})},
+				{Concealed: TileBag{}},
+			},
+		}
+	}
+	t.Run("cannot win off a tile passed on", func(t *testing.T) {
+		r := newRound(Rules{SacredDiscard: true})
+		now := time.Now()
+		assert.NoError(t, r.Draw(1, now))
+		assert.Equal(t, []Tile{TileDragonsRed}, r.Passed[2])
+		assert.Equal(t, []Tile{TileDragonsRed}, r.View(2).SacredDiscards)
+		assert.Empty(t, r.View(0).SacredDiscards)
+		assert.NoError(t, r.Discard(1, now, TileDragonsRed))
+		err := r.Hu(2, now)
+		assert.EqualError(t, err, "sacred discard")
+	})
+	t.Run("drawing clears passed tiles", func(t *testing.T) {
+		r := newRound(Rules{SacredDiscard: true})
+		r.Passed[1] = []Tile{TileDots1}
+		now := time.Now()
+		assert.NoError(t, r.Draw(1, now))
+		assert.Empty(t, r.Passed[1])
+	})
+	t.Run("rule disabled", func(t *testing.T) {
+		r := newRound(Rules{})
+		now := time.Now()
+		assert.NoError(t, r.Draw(1, now))
+		assert.NoError(t, r.Discard(1, now, TileDragonsRed))
+		assert.NoError(t, r.Hu(2, now))
+	})
+}
+
+func TestRound_Rivers(t *testing.T) {
+	r := &Round{
+		Wall:     []Tile{TileDots1, TileDots2, TileDots3},
+		DeadWall: []Tile{TileDots4},
+		Turn:     0,
+		Phase:    PhaseDraw,
+		Hands: [4]Hand{
+			{Concealed: TileBag{TileDragonsRed: 1}},
+			{Concealed: TileBag{TileDots1: 2, TileWindsEast: 1}},
+			{},
+			{},
+		},
+	}
+	now := time.Now()
+	assert.NoError(t, r.Draw(0, now))
+	assert.NoError(t, r.Discard(0, now, TileDots1))
+	assert.NoError(t, r.Pong(1, now))
+	assert.NoError(t, r.Discard(1, now, TileWindsEast))
+	seat1 := 1
+	assert.Equal(t, [4][]DiscardedTile{
+		{{Tile: TileDots1, ClaimedBy: &seat1, Tsumogiri: true}},
+		{{Tile: TileWindsEast}},
+	}, r.Rivers)
+	assert.Equal(t, r.Rivers, r.View(-1).Rivers)
+}
+
+func TestRound_Rivers_tsumogiri(t *testing.T) {
+	t.Run("replacement for a drawn flower", func(t *testing.T) {
+		r := &Round{
+			Wall:     []Tile{TileGentlemen1, TileDots2, TileDots3},
+			DeadWall: []Tile{TileDots4},
+			Turn:     0,
+			Phase:    PhaseDraw,
+			Hands:    [4]Hand{{Concealed: TileBag{TileDragonsRed: 1}}},
+		}
+		now := time.Now()
+		assert.NoError(t, r.Draw(0, now))
+		assert.NoError(t, r.Discard(0, now, TileDots4))
+		assert.Equal(t, []DiscardedTile{{Tile: TileDots4, Tsumogiri: true}}, r.Rivers[0])
+	})
+	t.Run("replacement for a flower dealt to the dealer", func(t *testing.T) {
+		hand := []Tile{
+			TileGentlemen1,
+			TileBamboo1, TileBamboo1, TileBamboo1,
+			TileBamboo2, TileBamboo2, TileBamboo2,
+			TileBamboo3, TileBamboo3, TileBamboo3,
+			TileBamboo4, TileBamboo4, TileBamboo4,
+			TileBamboo5,
+		}
+		wall := append([]Tile(nil), hand...)
+		for i := 0; i < 20; i++ {
+			wall = append(wall, TileDots9)
+		}
+		r := &Round{}
+		err := r.Replay([]Event{
+			{Type: EventStart, Tiles: wall, Visibility: VisibilityHidden},
+			{Type: EventDeal, Tiles: hand, Visibility: VisibilityOwner},
+			{Type: EventFlower, Tiles: []Tile{TileGentlemen1}},
+			{Type: EventReplace, Tiles: []Tile{TileDots9}, Visibility: VisibilityOwner},
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, r.Discard(0, time.Now(), TileDots9))
+		assert.Equal(t, []DiscardedTile{{Tile: TileDots9}}, r.Rivers[0])
+	})
+}
+
+func TestRound_unseen(t *testing.T) {
+	seat1 := 1
+	r := &Round{
+		Discards: []Tile{TileDots1, TileDragonsRed},
+		Hands: [4]Hand{
+			{Concealed: TileBag{TileDots1: 1, TileDots2: 1}, Flowers: []Tile{TileCat}},
+			{Revealed: Melds{{Type: MeldPong, Tiles: []Tile{TileDots2}, Feeder: &seat1}}, Concealed: TileBag{TileDots3: 1}},
+			{Finished: []Tile{TileDots1, TileDots1}},
+			{Concealed: TileBag{TileDots4: 1}, Exposed: true},
+		},
+	}
+	t.Run("from seat", func(t *testing.T) {
+		unseen := r.unseen(0)
+		assert.Len(t, unseen, 34)
+		assert.Equal(t, 0, unseen[TileDots1])
+		assert.Equal(t, 0, unseen[TileDots2])
+		assert.Equal(t, 4, unseen[TileDots3])
+		assert.Equal(t, 3, unseen[TileDots4])
+		assert.Equal(t, 3, unseen[TileDragonsRed])
+		assert.NotContains(t, unseen, TileCat)
+	})
+	t.Run("bystander", func(t *testing.T) {
+		unseen := r.unseen(-1)
+		assert.Equal(t, 1, unseen[TileDots1])
+		assert.Equal(t, 1, unseen[TileDots2])
+	})
+}
+
+func TestRound_MarshalJSON(t *testing.T) {
+	var ms int64 = 1598707747116
+	now := time.Unix(ms/1000, (ms%1000)*1e6)
+	r := &Round{
+		Rules:            RulesShooter,
+		ReservedDuration: 2 * time.Second,
+	}
+	r.Start(0, now)
+	_ = r.Discard(0, now, TileCharacters8)
+	data, err := json.Marshal(r)
+	assert.NoError(t, err)
+	var got Round
+	err = json.Unmarshal(data, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, r, &got)
+}
+
+func TestRound_UnmarshalJSON(t *testing.T) {
+	t.Run("round saved as a snapshot survives being saved again", func(t *testing.T) {
+		now := time.Unix(1598707747, 0)
+		started := &Round{Rules: RulesShooter}
+		started.Start(0, now)
+		// rounds used to be saved with a start event without any tiles
+		legacy := *started
+		legacy.Events = []Event{{Type: EventStart, Time: timeInMillis(now)}}
+		data, err := json.Marshal(snapshot(legacy))
+		require.NoError(t, err)
+
+		var loaded Round
+		require.NoError(t, json.Unmarshal(data, &loaded))
+		assert.Equal(t, started.Wall, loaded.Wall)
+		assert.Equal(t, started.Hands, loaded.Hands)
+		var discard Tile
+		for tile := range loaded.Hands[loaded.Turn].Concealed {
+			discard = tile
+			break
+		}
+		require.NoError(t, loaded.Discard(loaded.Turn, now, discard))
+
+		data, err = json.Marshal(loaded)
+		require.NoError(t, err)
+		var reloaded Round
+		require.NoError(t, json.Unmarshal(data, &reloaded))
+		assert.Equal(t, loaded.Hands, reloaded.Hands)
+		assert.Equal(t, loaded.Wall, reloaded.Wall)
+		assert.Equal(t, loaded.DeadWall, reloaded.DeadWall)
+		assert.Equal(t, loaded.Discards, reloaded.Discards)
+		assert.Equal(t, loaded.Rivers, reloaded.Rivers)
+		assert.Equal(t, loaded.Turn, reloaded.Turn)
+		assert.Equal(t, loaded.Phase, reloaded.Phase)
+		assert.Equal(t, loaded.Events, reloaded.Events)
+	})
 }
 
 func TestRound_End(t *testing.T) {
@@ -959,9 +1517,13 @@ func TestRound_End(t *testing.T) {
 			Wind:   r.Wind,
 			Winner: -1,
 			Loser:  -1,
+			Reason: ReasonWallExhausted,
 		}, r.Result)
 		assert.Equal(t, now, r.LastActionTime)
-		assert.Equal(t, []Event{{Type: EventEnd, Seat: 0, Time: timeInMillis(now)}}, r.Events)
+		assert.Equal(t, []Event{
+			{Type: EventEnd, Seat: 0, Time: timeInMillis(now)},
+			{Type: EventResult, Seat: 0, Time: timeInMillis(now), Result: r.Result},
+		}, r.Events)
 	})
 }
 
@@ -969,7 +1531,7 @@ func TestRound_Next(t *testing.T) {
 	t.Run("cannot create next round when current round is not finished", func(t *testing.T) {
 		r := &Round{Finished: false}
 		_, err := r.Next()
-		assert.EqualError(t, err, "unfinished")
+		assert.Equal(t, ErrUnfinished, err)
 	})
 	t.Run("dealer does not win and dealer moves on", func(t *testing.T) {
 		r := &Round{
@@ -1009,6 +1571,47 @@ func TestRound_Next(t *testing.T) {
 		assert.Equal(t, 0, next.Dealer)
 		assert.Equal(t, DirectionSouth, next.Wind)
 	})
+	t.Run("dealer streak increases when dealer wins", func(t *testing.T) {
+		r := &Round{
+			Finished: true,
+			Dealer:   2,
+			Streak:   1,
+			Result: &Result{
+				Winner: 2,
+			},
+		}
+		next, err := r.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, next.Streak)
+	})
+	t.Run("dealer moves on after draw by default", func(t *testing.T) {
+		r := &Round{
+			Finished: true,
+			Dealer:   2,
+			Streak:   1,
+			Result: &Result{
+				Winner: -1,
+			},
+		}
+		next, err := r.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, 3, next.Dealer)
+		assert.Equal(t, 0, next.Streak)
+	})
+	t.Run("dealer remains dealer after draw if rules allow", func(t *testing.T) {
+		r := &Round{
+			Finished: true,
+			Dealer:   2,
+			Rules:    Rules{DealerKeepsOnDraw: true},
+			Result: &Result{
+				Winner: -1,
+			},
+		}
+		next, err := r.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, next.Dealer)
+		assert.Equal(t, 1, next.Streak)
+	})
 	t.Run("copies over round settings", func(t *testing.T) {
 		r := &Round{
 			Scores:           [4]int{4, 2, 1, -2},
@@ -0,0 +1,237 @@
+package mahjong
+
+import "sync"
+
+// tileKinds are the kinds of tiles which can form melds, in order: the
+// numbered suits, then the winds and dragons.
+var tileKinds = [34]Tile{
+	TileDots1, TileDots2, TileDots3, TileDots4, TileDots5, TileDots6, TileDots7, TileDots8, TileDots9,
+	TileBamboo1, TileBamboo2, TileBamboo3, TileBamboo4, TileBamboo5, TileBamboo6, TileBamboo7, TileBamboo8, TileBamboo9,
+	TileCharacters1, TileCharacters2, TileCharacters3, TileCharacters4, TileCharacters5, TileCharacters6, TileCharacters7, TileCharacters8, TileCharacters9,
+	TileWindsEast, TileWindsSouth, TileWindsWest, TileWindsNorth,
+	TileDragonsRed, TileDragonsGreen, TileDragonsWhite,
+}
+
+// kindIndex is the index of each tile in tileKinds.
+var kindIndex = make(map[Tile]int)
+
+func init() {
+	for i, tile := range tileKinds {
+		kindIndex[tile] = i
+	}
+}
+
+// tileCounts counts the tiles of each kind in a hand, along with the number
+// of jokers.
+type tileCounts struct {
+	kinds  [34]int
+	jokers int
+}
+
+func countTiles(bag TileBag) tileCounts {
+	var counts tileCounts
+	for tile, n := range bag {
+		if tile == TileJoker {
+			counts.jokers += n
+		} else if i, ok := kindIndex[tile]; ok {
+			counts.kinds[i] += n
+		}
+	}
+	return counts
+}
+
+func (c *tileCounts) add(tile Tile, n int) {
+	if tile == TileJoker {
+		c.jokers += n
+	} else if i, ok := kindIndex[tile]; ok {
+		c.kinds[i] += n
+	}
+}
+
+// shanten returns how many tiles away a hand is from being ready to win when
+// it still needs a certain number of melds, or -1 if it is already a winning
+// hand. Each joker is taken to bring a hand one tile closer, which is exact
+// unless the hand would need more jokers than it has partial melds.
+func (c tileCounts) shanten(sets int) int {
+	best := 2 * sets
+	var suits [3][]shape
+	for i := range suits {
+		suits[i] = suitShapes(c.kinds[9*i : 9*i+9])
+	}
+	for _, honours := range honourShapes(c.kinds[27:]) {
+		for _, dots := range suits[0] {
+			for _, bamboo := range suits[1] {
+				for _, characters := range suits[2] {
+					total := shape{
+						melds:    honours.melds + dots.melds + bamboo.melds + characters.melds,
+						partials: honours.partials + dots.partials + bamboo.partials + characters.partials,
+						eye:      honours.eye + dots.eye + bamboo.eye + characters.eye,
+					}
+					if total.eye > 1 {
+						continue
+					}
+					if shanten := total.shanten(sets); shanten < best {
+						best = shanten
+					}
+				}
+			}
+		}
+	}
+	best -= c.jokers
+	if best < -1 {
+		return -1
+	}
+	return best
+}
+
+// shape is a way of breaking down part of a hand into melds, partial melds
+// which need one more tile and an eye.
+type shape struct {
+	melds, partials, eye int
+}
+
+func (s shape) shanten(sets int) int {
+	melds, partials := s.melds, s.partials
+	if melds > sets {
+		melds = sets
+	}
+	if melds+partials > sets {
+		partials = sets - melds
+	}
+	return 2*(sets-melds) - partials - s.eye
+}
+
+// honourShapes returns the ways winds and dragons can be broken down, which
+// cannot form sequences.
+func honourShapes(counts []int) []shape {
+	var base shape
+	triples := 0
+	for _, n := range counts {
+		switch {
+		case n >= 3:
+			base.melds++
+			triples++
+		case n == 2:
+			base.partials++
+		}
+	}
+	shapes := []shape{base}
+	if base.partials > 0 {
+		shapes = append(shapes, shape{base.melds, base.partials - 1, 1})
+	}
+	if triples > 0 {
+		shapes = append(shapes, shape{base.melds - 1, base.partials, 1})
+	}
+	return shapes
+}
+
+var (
+	suitShapesMu    sync.RWMutex
+	suitShapesCache = make(map[int][]shape)
+)
+
+// suitShapes returns the ways the tiles of a numbered suit can be broken
+// down. Shapes are cached by the number of each tile in the suit, since the
+// same suits come up again and again.
+func suitShapes(counts []int) []shape {
+	key := 0
+	for _, n := range counts {
+		key = key*5 + n
+	}
+	suitShapesMu.RLock()
+	shapes, ok := suitShapesCache[key]
+	suitShapesMu.RUnlock()
+	if ok {
+		return shapes
+	}
+	var search suitSearch
+	copy(search.counts[:], counts)
+	search.seen = make(map[shape]bool)
+	search.search(0, shape{})
+	shapes = search.shapes
+	suitShapesMu.Lock()
+	suitShapesCache[key] = shapes
+	suitShapesMu.Unlock()
+	return shapes
+}
+
+// suitSearch finds every way the tiles of a numbered suit can be broken
+// down.
+type suitSearch struct {
+	counts [9]int
+	seen   map[shape]bool
+	shapes []shape
+}
+
+func (s *suitSearch) search(i int, current shape) {
+	for i < len(s.counts) && s.counts[i] == 0 {
+		i++
+	}
+	if i == len(s.counts) {
+		if !s.seen[current] {
+			s.seen[current] = true
+			s.shapes = append(s.shapes, current)
+		}
+		return
+	}
+	k := &s.counts
+	if k[i] >= 3 {
+		k[i] -= 3
+		s.search(i, shape{current.melds + 1, current.partials, current.eye})
+		k[i] += 3
+	}
+	if i < 7 && k[i+1] > 0 && k[i+2] > 0 {
+		k[i]--
+		k[i+1]--
+		k[i+2]--
+		s.search(i, shape{current.melds + 1, current.partials, current.eye})
+		k[i]++
+		k[i+1]++
+		k[i+2]++
+	}
+	if k[i] >= 2 {
+		k[i] -= 2
+		if current.eye == 0 {
+			s.search(i, shape{current.melds, current.partials, 1})
+		}
+		s.search(i, shape{current.melds, current.partials + 1, current.eye})
+		k[i] += 2
+	}
+	if i < 8 && k[i+1] > 0 {
+		k[i]--
+		k[i+1]--
+		s.search(i, shape{current.melds, current.partials + 1, current.eye})
+		k[i]++
+		k[i+1]++
+	}
+	if i < 7 && k[i+2] > 0 {
+		k[i]--
+		k[i+2]--
+		s.search(i, shape{current.melds, current.partials + 1, current.eye})
+		k[i]++
+		k[i+2]++
+	}
+	// leave the rest of the tiles of this kind unused
+	n := k[i]
+	k[i] = 0
+	s.search(i+1, current)
+	k[i] = n
+}
+
+// liveTiles returns how many unseen tiles would bring a hand closer to
+// winning if drawn.
+func (c tileCounts) liveTiles(sets int, unseen map[Tile]int) int {
+	shanten := c.shanten(sets)
+	live := 0
+	for tile, n := range unseen {
+		if n == 0 {
+			continue
+		}
+		c.add(tile, 1)
+		if c.shanten(sets) < shanten {
+			live += n
+		}
+		c.add(tile, -1)
+	}
+	return live
+}
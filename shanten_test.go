@@ -0,0 +1,81 @@
+package mahjong
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_tileCounts_shanten(t *testing.T) {
+	tests := []struct {
+		name  string
+		tiles []Tile
+		sets  int
+		want  int
+	}{
+		{
+			name: "winning hand",
+			tiles: []Tile{
+				TileDots1, TileDots2, TileDots3, TileDots4, TileDots5, TileDots6, TileBamboo7, TileBamboo7, TileBamboo7,
+				TileCharacters2, TileCharacters3, TileCharacters4, TileDragonsRed, TileDragonsRed,
+			},
+			sets: 4,
+			want: -1,
+		},
+		{
+			name: "ready hand",
+			tiles: []Tile{
+				TileDots1, TileDots2, TileDots3, TileDots4, TileDots5, TileDots6, TileBamboo7, TileBamboo7, TileBamboo7,
+				TileCharacters2, TileCharacters3, TileDragonsRed, TileDragonsRed,
+			},
+			sets: 4,
+			want: 0,
+		},
+		{
+			name: "two away",
+			tiles: []Tile{
+				TileDots1, TileDots2, TileDots3, TileDots4, TileDots5, TileDots6, TileBamboo7, TileBamboo7, TileBamboo9,
+				TileCharacters2, TileCharacters3, TileDragonsRed, TileWindsEast,
+			},
+			sets: 4,
+			want: 2,
+		},
+		{
+			name: "unconnected tiles",
+			tiles: []Tile{
+				TileDots1, TileDots4, TileDots7, TileBamboo1, TileBamboo4, TileBamboo7, TileCharacters1, TileCharacters4, TileCharacters7,
+				TileWindsEast, TileWindsSouth, TileWindsWest, TileDragonsRed,
+			},
+			sets: 4,
+			want: 8,
+		},
+		{
+			name:  "revealed melds need fewer sets",
+			tiles: []Tile{TileBamboo2, TileBamboo3, TileDragonsRed, TileDragonsRed},
+			sets:  1,
+			want:  0,
+		},
+		{
+			name:  "jokers stand in for missing tiles",
+			tiles: []Tile{TileBamboo2, TileBamboo3, TileDragonsRed, TileJoker, TileJoker},
+			sets:  1,
+			want:  -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, countTiles(NewTileBag(tt.tiles)).shanten(tt.sets))
+		})
+	}
+}
+
+func Test_tileCounts_liveTiles(t *testing.T) {
+	counts := countTiles(NewTileBag([]Tile{TileBamboo2, TileBamboo3, TileDragonsRed, TileDragonsRed}))
+	unseen := map[Tile]int{
+		TileBamboo1:    4,
+		TileBamboo4:    2,
+		TileBamboo5:    4,
+		TileDragonsRed: 1,
+	}
+	assert.Equal(t, 6, counts.liveTiles(1, unseen))
+}
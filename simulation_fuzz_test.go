@@ -0,0 +1,21 @@
+//go:build go1.18
+// +build go1.18
+
+package mahjong
+
+import (
+	"testing"
+)
+
+// FuzzRandomPlay plays a round with the actions chosen by the fuzzer, falling
+// back to random play from a seed once they run out. A failure is reproduced
+// by its seed and the choices of actions, which the fuzzer shrinks.
+func FuzzRandomPlay(f *testing.F) {
+	for seed := int64(0); seed < 4; seed++ {
+		f.Add(seed, []byte{})
+	}
+	f.Add(int64(1), []byte{0, 1, 2, 3, 4, 5, 6, 7})
+	f.Fuzz(func(t *testing.T, seed int64, choices []byte) {
+		simulate(t, seed, choices, 1)
+	})
+}
@@ -0,0 +1,240 @@
+package mahjong
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomRules returns rules exercising different combinations of options
+// depending on a seed.
+func randomRules(seed int64) Rules {
+	rng := rand.New(rand.NewSource(seed))
+	falseWins := []FalseWinRule{FalseWinAllowed, FalseWinExposed, FalseWinDeadHand}
+	rules := Rules{
+		Shooter:              rng.Intn(2) == 0,
+		Limit:                3 + rng.Intn(3),
+		DealerKeepsOnDraw:    rng.Intn(2) == 0,
+		StreakPoints:         rng.Intn(2),
+		AbortOnFourKongs:     rng.Intn(2) == 0,
+		AbortOnFourWinds:     rng.Intn(2) == 0,
+		AbortOnNineTerminals: rng.Intn(2) == 0,
+		FalseWin:             falseWins[rng.Intn(len(falseWins))],
+		FalseWinPenalty:      rng.Intn(3),
+		Liability:            rng.Intn(2) == 0,
+		SacredDiscard:        rng.Intn(2) == 0,
+	}
+	if rng.Intn(4) == 0 {
+		rules.Tiles.Jokers = 4
+	}
+	if rng.Intn(4) == 0 {
+		rules.Tiles.NoFlowers = true
+	}
+	if rng.Intn(4) == 0 {
+		rules.Tiles.NoAnimals = true
+	}
+	if rng.Intn(4) == 0 {
+		rules.Tiles.NoHonours = true
+	}
+	if rng.Intn(4) == 0 {
+		suits := []Suit{SuitDots, SuitBamboo, SuitCharacters}
+		rng.Shuffle(len(suits), func(i, j int) {
+			suits[i], suits[j] = suits[j], suits[i]
+		})
+		rules.Tiles.Suits = suits[:1+rng.Intn(2)]
+	}
+	if rules.Tiles.Validate() != nil {
+		// one suit without honours is too small to deal
+		rules.Tiles.NoHonours = false
+	}
+	return rules
+}
+
+// simulation plays rounds with random legal actions. Choices, if any, pick
+// the actions taken before falling back to the random number generator, so
+// that a failing game can be reproduced and shrunk by fuzzing.
+type simulation struct {
+	t       testing.TB
+	rng     *rand.Rand
+	choices []byte
+	log     []string
+}
+
+func newSimulation(t testing.TB, seed int64, choices []byte) *simulation {
+	return &simulation{
+		t:       t,
+		rng:     rand.New(rand.NewSource(seed)),
+		choices: choices,
+	}
+}
+
+func (s *simulation) choose(n int) int {
+	if len(s.choices) > 0 {
+		choice := int(s.choices[0]) % n
+		s.choices = s.choices[1:]
+		return choice
+	}
+	return s.rng.Intn(n)
+}
+
+// play plays a round to the end and returns the time it ended.
+func (s *simulation) play(r *Round, now time.Time) time.Time {
+	s.t.Helper()
+	s.check(r)
+	for steps := 0; !r.Finished; steps++ {
+		if steps == 1000 {
+			s.fatalf("round did not finish")
+		}
+		// occasionally act within the reserved duration after the last
+		// action, when it may still be undone
+		if s.choose(8) == 0 {
+			now = now.Add(r.ReservedDuration / 2)
+		} else {
+			now = now.Add(2 * r.ReservedDuration)
+		}
+		type choice struct {
+			seat   int
+			action Action
+		}
+		var choices []choice
+		for seat := 0; seat < 4; seat++ {
+			for _, action := range r.LegalActions(seat, now) {
+				choices = append(choices, choice{seat, action})
+			}
+		}
+		if len(choices) == 0 {
+			// everyone is waiting for the reserved duration to pass
+			if !now.Before(r.LastActionTime.Add(r.ReservedDuration)) {
+				s.fatalf("no legal actions")
+			}
+			continue
+		}
+		// occasionally declare a win without a winning hand
+		if r.Rules.FalseWin != FalseWinAllowed && s.choose(32) == 0 {
+			seat := s.choose(4)
+			s.log = append(s.log, fmt.Sprintf("seat %d: hu (false)", seat))
+			_ = r.Hu(seat, now)
+			s.check(r)
+			continue
+		}
+		c := choices[s.choose(len(choices))]
+		s.log = append(s.log, fmt.Sprintf("seat %d: %s %v", c.seat, c.action.Type, c.action.Tiles))
+		if err := r.Act(c.seat, now, c.action); err != nil {
+			s.fatalf("legal action failed: %v", err)
+		}
+		s.check(r)
+	}
+	return now
+}
+
+// fatalf stops a simulation, listing the actions taken in the current round
+// so far.
+func (s *simulation) fatalf(format string, args ...interface{}) {
+	s.t.Helper()
+	s.t.Fatalf(format+"\nactions:\n%s", append(args, strings.Join(s.log, "\n"))...)
+}
+
+// check asserts the invariants which must hold after every action.
+func (s *simulation) check(r *Round) {
+	s.t.Helper()
+	if err := r.Validate(); err != nil {
+		s.fatalf("invalid round: %v", err)
+	}
+	sum := 0
+	for _, score := range r.Scores {
+		sum += score
+	}
+	if sum != 0 {
+		s.fatalf("scores %v do not sum to zero", r.Scores)
+	}
+	for seat := -1; seat < 4; seat++ {
+		view := r.View(seat)
+		for i, hand := range view.Hands {
+			if i == seat || hand.Exposed {
+				continue
+			}
+			for tile := range hand.Concealed {
+				if tile != "" {
+					s.fatalf("seat %d can see concealed tiles of seat %d", seat, i)
+				}
+			}
+		}
+		for _, e := range view.Events {
+			hidden := e.Visibility == VisibilityHidden || e.Visibility == VisibilityOwner && e.Seat != seat
+			if hidden && len(e.Tiles) > 0 {
+				s.fatalf("seat %d can see tiles of %s event for seat %d", seat, e.Type, e.Seat)
+			}
+		}
+	}
+}
+
+// checkNext asserts that a round was followed by the right one.
+func (s *simulation) checkNext(r, next *Round) {
+	keep := r.Result.Winner == r.Dealer || r.Result.Winner == -1 && r.Rules.DealerKeepsOnDraw
+	assert.Equal(s.t, r.Scores, next.Scores)
+	if keep {
+		assert.Equal(s.t, r.Dealer, next.Dealer)
+		assert.Equal(s.t, r.Wind, next.Wind)
+		assert.Equal(s.t, r.Streak+1, next.Streak)
+		return
+	}
+	assert.Equal(s.t, (r.Dealer+1)%4, next.Dealer)
+	assert.Zero(s.t, next.Streak)
+	if next.Dealer == 0 {
+		assert.Equal(s.t, r.Wind+1, next.Wind)
+	} else {
+		assert.Equal(s.t, r.Wind, next.Wind)
+	}
+}
+
+// simulate plays a number of rounds, starting each after the previous one.
+func simulate(t testing.TB, seed int64, choices []byte, rounds int) {
+	s := newSimulation(t, seed, choices)
+	r := &Round{
+		Rules:            randomRules(seed),
+		ReservedDuration: time.Second,
+	}
+	now := time.Unix(0, 0)
+	for i := 0; i < rounds; i++ {
+		s.log = nil
+		r.Start(s.rng.Int63(), now)
+		now = s.play(r, now)
+		next, err := r.Next()
+		if errors.Is(err, ErrNoMoreRounds) {
+			return
+		}
+		require.NoError(t, err)
+		s.checkNext(r, next)
+		r = next
+	}
+}
+
+// simulations is the number of seeds TestRandomPlay plays a game of up to
+// simulationRounds rounds with, which takes around a second each, for about
+// three thousand rounds in all. Only shortSimulations games are played with
+// -short.
+var simulations = flag.Int("simulations", 200, "number of games to simulate with random play")
+
+const (
+	simulationRounds = 16
+	shortSimulations = 10
+)
+
+func TestRandomPlay(t *testing.T) {
+	seeds := *simulations
+	if testing.Short() && seeds > shortSimulations {
+		seeds = shortSimulations
+	}
+	for seed := int64(0); seed < int64(seeds); seed++ {
+		t.Run(fmt.Sprint(seed), func(t *testing.T) {
+			simulate(t, seed, nil, simulationRounds)
+		})
+	}
+}
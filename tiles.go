@@ -1,5 +1,13 @@
 package mahjong
 
+import (
+	"errors"
+)
+
+// ErrTooFewTiles is returned for a tile set which is too small to deal a
+// round from.
+var ErrTooFewTiles = errors.New("too few tiles")
+
 // Tile represents a mahjong tile.
 type Tile string
 
@@ -51,6 +59,7 @@ const (
 	TileDragonsRed   Tile = "44红中"
 	TileDragonsGreen Tile = "45青发"
 	TileDragonsWhite Tile = "46白板"
+	TileJoker        Tile = "47百搭"
 )
 
 type Suit int
@@ -63,9 +72,11 @@ const (
 	SuitCharacters
 	SuitDragons
 	SuitWinds
+	SuitJokers
 )
 
 var (
+	animalTiles = []Tile{TileCat, TileRat, TileRooster, TileCentipede}
 	flowerTiles = []Tile{TileCat, TileRat, TileRooster, TileCentipede, TileGentlemen1, TileGentlemen2, TileGentlemen3, TileGentlemen4, TileSeasons1, TileSeasons2, TileSeasons3, TileSeasons4}
 	suitedTiles = []Tile{
 		TileDots1, TileDots2, TileDots3, TileDots4, TileDots5, TileDots6, TileDots7, TileDots8, TileDots9,
@@ -116,6 +127,8 @@ func (t Tile) Suit() Suit {
 		return SuitWinds
 	case t == TileDragonsRed || t == TileDragonsGreen || t == TileDragonsWhite:
 		return SuitDragons
+	case t == TileJoker:
+		return SuitJokers
 	}
 	return 0
 }
@@ -124,6 +137,17 @@ func isFlower(tile Tile) bool {
 	return tile.Suit() == SuitFlowers
 }
 
+// isTerminalOrHonour reports whether a tile is a one, a nine, a wind or a
+// dragon.
+func isTerminalOrHonour(tile Tile) bool {
+	switch tile {
+	case TileDots1, TileDots9, TileBamboo1, TileBamboo9, TileCharacters1, TileCharacters9:
+		return true
+	}
+	suit := tile.Suit()
+	return suit == SuitWinds || suit == SuitDragons
+}
+
 // sequences is a map of tiles to valid tiles for completing a sequence.
 var sequences = map[Tile][][2]Tile{
 	TileDots1:       {{TileDots2, TileDots3}},
@@ -167,3 +191,106 @@ func isValidSequence(tile0, tile1, tile2 Tile) bool {
 	}
 	return false
 }
+
+// TileSet determines which tiles are used to build the wall. The zero value
+// is the full set of suited tiles, honours, flowers and animals.
+type TileSet struct {
+	// NoFlowers leaves out the gentlemen and seasons.
+	NoFlowers bool `json:"no_flowers,omitempty"`
+
+	// NoAnimals leaves out the cat, rat, rooster and centipede.
+	NoAnimals bool `json:"no_animals,omitempty"`
+
+	// NoHonours leaves out the winds and dragons.
+	NoHonours bool `json:"no_honours,omitempty"`
+
+	// Suits are the suits of numbered tiles used. All three suits are used
+	// if Suits is empty.
+	Suits []Suit `json:"suits,omitempty"`
+
+	// Jokers is the number of jokers added, which can stand in for any tile
+	// in a winning hand.
+	Jokers int `json:"jokers,omitempty"`
+}
+
+// Tiles returns every tile in a set.
+func (s TileSet) Tiles() []Tile {
+	var tiles []Tile
+	for _, tile := range flowerTiles {
+		if s.inPlay(tile) {
+			tiles = append(tiles, tile)
+		}
+	}
+	for _, tile := range suitedTiles {
+		if s.inPlay(tile) {
+			tiles = append(tiles, tile, tile, tile, tile)
+		}
+	}
+	for i := 0; i < s.Jokers; i++ {
+		tiles = append(tiles, TileJoker)
+	}
+	return tiles
+}
+
+// inPlay reports whether a kind of tile is part of a set.
+func (s TileSet) inPlay(tile Tile) bool {
+	switch suit := tile.Suit(); suit {
+	case SuitFlowers:
+		if contains(animalTiles, tile) {
+			return !s.NoAnimals
+		}
+		return !s.NoFlowers
+	case SuitWinds, SuitDragons:
+		return !s.NoHonours
+	case SuitJokers:
+		return s.Jokers > 0
+	default:
+		if len(s.Suits) == 0 {
+			return true
+		}
+		for _, allowed := range s.Suits {
+			if suit == allowed {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// isFlower reports whether a tile is a flower or animal in play, which must be
+// set aside and replaced when drawn.
+func (s TileSet) isFlower(tile Tile) bool {
+	return isFlower(tile) && s.inPlay(tile)
+}
+
+// hasFlowers reports whether a set contains any flowers or animals.
+func (s TileSet) hasFlowers() bool {
+	return !s.NoFlowers || !s.NoAnimals
+}
+
+// Validate returns ErrTooFewTiles if a set does not have enough tiles to deal
+// every hand and still leave tiles in the live wall and the dead wall.
+func (s TileSet) Validate() error {
+	if s.spareTiles() < 2 {
+		return ErrTooFewTiles
+	}
+	return nil
+}
+
+// bites returns the groups of flowers which bite when a certain flower is
+// added. Groups containing flowers which are not in play are left out.
+func (s TileSet) bites(flower Tile) []FlowerGroup {
+	var groups []FlowerGroup
+	for _, group := range bites[flower] {
+		complete := true
+		for _, tile := range group.Flowers {
+			if !s.inPlay(tile) {
+				complete = false
+			}
+		}
+		if complete {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
@@ -10,3 +10,47 @@ func Test_isValidSequence(t *testing.T) {
 	tile0, tile1, tile2 := TileBamboo4, TileBamboo2, TileBamboo3
 	assert.True(t, isValidSequence(tile0, tile1, tile2))
 }
+
+func TestTileSet_Tiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		set   TileSet
+		count int
+	}{
+		{"default", TileSet{}, 148},
+		{"no flowers", TileSet{NoFlowers: true}, 140},
+		{"no animals", TileSet{NoAnimals: true}, 144},
+		{"jokers", TileSet{Jokers: 4}, 152},
+		{"single suit", TileSet{NoFlowers: true, NoAnimals: true, NoHonours: true, Suits: []Suit{SuitBamboo}}, 36},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Len(t, tt.set.Tiles(), tt.count)
+		})
+	}
+}
+
+func TestTileSet_Validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		set          TileSet
+		deadWallSize int
+		err          error
+	}{
+		{"default", TileSet{}, DeadWallSize, nil},
+		{"one suit", TileSet{NoFlowers: true, NoAnimals: true, Suits: []Suit{SuitDots}}, 5, nil},
+		{"one suit with flowers", TileSet{Suits: []Suit{SuitDots}}, 5, nil},
+		{"one suit without honours", TileSet{NoHonours: true, Suits: []Suit{SuitDots}}, 0, ErrTooFewTiles},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.err, tt.set.Validate())
+			assert.Equal(t, tt.deadWallSize, tt.set.deadWallSize())
+		})
+	}
+}
+
+func TestTileSet_bites(t *testing.T) {
+	assert.NotEmpty(t, TileSet{}.bites(TileCat))
+	assert.Empty(t, TileSet{NoAnimals: true}.bites(TileCat))
+}
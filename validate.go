@@ -0,0 +1,73 @@
+package mahjong
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Ways the state of a round can be inconsistent.
+var (
+	ErrTilesNotConserved = errors.New("tiles not conserved")
+	ErrWrongHandSize     = errors.New("wrong hand size")
+)
+
+// Validate checks that the state of a round is consistent. The wall, dead
+// wall, discards and every player's flowers, concealed tiles, revealed melds
+// and finished tiles must together be exactly the tiles in the round's tile
+// set, and until the round is finished, each hand must hold 13 tiles, or 14
+// for the player who is about to discard, counting each meld as three tiles.
+func (r *Round) Validate() error {
+	counts := make(map[Tile]int)
+	for _, tile := range r.Rules.Tiles.Tiles() {
+		counts[tile]++
+	}
+	found := make(map[Tile]int)
+	add := func(tiles ...Tile) {
+		for _, tile := range tiles {
+			found[tile]++
+		}
+	}
+	add(r.Wall...)
+	add(r.DeadWall...)
+	add(r.Discards...)
+	for _, hand := range r.Hands {
+		add(hand.Flowers...)
+		add(hand.Revealed.Tiles()...)
+		add(hand.Finished...)
+		for tile, count := range hand.Concealed {
+			found[tile] += count
+		}
+	}
+	var tiles []Tile
+	for tile := range counts {
+		tiles = append(tiles, tile)
+	}
+	for tile := range found {
+		if _, ok := counts[tile]; !ok {
+			tiles = append(tiles, tile)
+		}
+	}
+	sort.Slice(tiles, func(i, j int) bool {
+		return tiles[i] < tiles[j]
+	})
+	for _, tile := range tiles {
+		if found[tile] != counts[tile] {
+			return fmt.Errorf("%w: found %d of %q instead of %d", ErrTilesNotConserved, found[tile], tile, counts[tile])
+		}
+	}
+	if r.Finished {
+		return nil
+	}
+	for seat, hand := range r.Hands {
+		size := hand.Concealed.Cardinality() + 3*len(hand.Revealed)
+		expected := 13
+		if seat == r.Turn && r.Phase == PhaseDiscard {
+			expected = 14
+		}
+		if size != expected {
+			return fmt.Errorf("%w: seat %d has %d tiles instead of %d", ErrWrongHandSize, seat, size, expected)
+		}
+	}
+	return nil
+}
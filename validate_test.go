@@ -0,0 +1,70 @@
+package mahjong
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRound_Validate(t *testing.T) {
+	t.Run("valid after every action", func(t *testing.T) {
+		for seed := int64(0); seed < 20; seed++ {
+			rng := rand.New(rand.NewSource(seed))
+			r := &Round{
+				Rules:            Rules{Limit: 5, Tiles: TileSet{Jokers: int(seed % 2 * 4)}},
+				ReservedDuration: time.Second,
+			}
+			now := time.Unix(0, 0)
+			r.Start(seed, now)
+			if !assert.NoError(t, r.Validate(), "seed %d after start", seed) {
+				return
+			}
+			for !r.Finished {
+				now = now.Add(2 * time.Second)
+				type choice struct {
+					seat   int
+					action Action
+				}
+				var choices []choice
+				for seat := 0; seat < 4; seat++ {
+					for _, action := range r.LegalActions(seat, now) {
+						choices = append(choices, choice{seat, action})
+					}
+				}
+				if !assert.NotEmpty(t, choices, "seed %d", seed) {
+					return
+				}
+				c := choices[rng.Intn(len(choices))]
+				if !assert.NoError(t, r.Act(c.seat, now, c.action)) {
+					return
+				}
+				if !assert.NoError(t, r.Validate(), "seed %d after %v by %d", seed, c.action, c.seat) {
+					return
+				}
+			}
+		}
+	})
+	t.Run("created tile", func(t *testing.T) {
+		r := &Round{}
+		r.Start(0, time.Now())
+		r.Hands[1].Concealed.Add(TileDots1)
+		assert.True(t, errors.Is(r.Validate(), ErrTilesNotConserved))
+	})
+	t.Run("destroyed tile", func(t *testing.T) {
+		r := &Round{}
+		r.Start(0, time.Now())
+		r.Wall = r.Wall[1:]
+		assert.True(t, errors.Is(r.Validate(), ErrTilesNotConserved))
+	})
+	t.Run("wrong hand size", func(t *testing.T) {
+		r := &Round{}
+		r.Start(0, time.Now())
+		tile := r.Wall[0]
+		r.Wall = r.Wall[1:]
+		r.Hands[2].Concealed.Add(tile)
+		assert.EqualError(t, r.Validate(), "wrong hand size: seat 2 has 14 tiles instead of 13")
+	})
+}
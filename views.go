@@ -2,22 +2,36 @@ package mahjong
 
 // RoundView represents a player's view of a round.
 type RoundView struct {
-	Seat      int       `json:"seat"`
-	Scores    [4]int    `json:"scores"`
-	Hands     [4]Hand   `json:"hands"`
-	DrawsLeft int       `json:"draws_left"`
-	Discards  []Tile    `json:"discards"`
-	Wind      Direction `json:"wind"`
-	Dealer    int       `json:"dealer"`
-	Turn      int       `json:"turn"`
-	Phase     Phase     `json:"phase"`
-	Events    []Event   `json:"events"`
-	Result    *Result   `json:"result,omitempty"`
-	Finished  bool      `json:"finished"`
+	Seat      int                `json:"seat"`
+	Scores    [4]int             `json:"scores"`
+	Hands     [4]Hand            `json:"hands"`
+	DrawsLeft int                `json:"draws_left"`
+	Dice      []int              `json:"dice"`
+	Discards  []Tile             `json:"discards"`
+	Rivers    [4][]DiscardedTile `json:"rivers"`
+	Wind      Direction          `json:"wind"`
+	Dealer    int                `json:"dealer"`
+	Streak    int                `json:"streak"`
+	Turn      int                `json:"turn"`
+	Phase     Phase              `json:"phase"`
+	Events    []Event            `json:"events"`
+	Result    *Result            `json:"result,omitempty"`
+	Finished  bool               `json:"finished"`
 
 	// LastActionTime is the time the last action took place represented in milliseconds since the Unix epoch.
 	LastActionTime int64 `json:"last_action_time"`
 
 	// ReservedDuration is a duration in milliseconds reserved for players to pong or gang after a discard.
 	ReservedDuration int64 `json:"reserved_duration"`
+
+	// Actions are the actions the viewing player can take once the reserved duration is over.
+	Actions []Action `json:"actions"`
+
+	// Unseen is how many copies of each kind of tile in play the viewing
+	// player has not seen, in their own hand or on the table.
+	Unseen map[Tile]int `json:"unseen"`
+
+	// SacredDiscards are the tiles the viewing player passed on winning with
+	// and may not win off another player's discard of.
+	SacredDiscards []Tile `json:"sacred_discards,omitempty"`
 }
@@ -0,0 +1,142 @@
+package mahjong
+
+import (
+	"math/rand"
+)
+
+// DeadWallSize is the number of tiles kept back in the dead wall. Tiles in
+// the dead wall are only drawn as replacements after a flower or a gang, and
+// the dead wall is topped up from the end of the live wall after each
+// replacement. A round is drawn once the live wall is empty. Tile sets too
+// small to keep back DeadWallSize tiles have a smaller dead wall.
+const DeadWallSize = 15
+
+// dealtTiles is the number of tiles dealt at the start of a round: 13 to each
+// player and one more to the dealer.
+const dealtTiles = 4*13 + 1
+
+// spareTiles returns the number of tiles in a set which are left after
+// dealing, even if every flower is dealt and replaced from the wall.
+func (s TileSet) spareTiles() int {
+	tiles := s.Tiles()
+	spare := len(tiles) - dealtTiles
+	for _, tile := range tiles {
+		if s.isFlower(tile) {
+			spare--
+		}
+	}
+	return spare
+}
+
+// deadWallSize returns the number of tiles kept back in the dead wall for a
+// set, which is never more than half of its spare tiles so that the live wall
+// is not used up by dealing.
+func (s TileSet) deadWallSize() int {
+	size := s.spareTiles() / 2
+	if size > DeadWallSize {
+		size = DeadWallSize
+	}
+	if size < 0 {
+		size = 0
+	}
+	return size
+}
+
+// The wall is built as four sides of stacked pairs of tiles, one side in front
+// of each player. Tiles in a wall are listed in the order they are drawn in,
+// starting from the top of the rightmost stack in front of the player in the
+// first seat. Within each side, stacks are taken from right to left, and
+// drawing carries on clockwise to the side of the player on the left.
+
+// newWall returns a shuffled wall built from a tile set.
+func newWall(r *rand.Rand, set TileSet) []Tile {
+	wall := set.Tiles()
+	r.Shuffle(len(wall), func(i, j int) {
+		wall[i], wall[j] = wall[j], wall[i]
+	})
+	return wall
+}
+
+// rollDice returns the result of rolling three dice.
+func rollDice(r *rand.Rand) []int {
+	return []int{r.Intn(6) + 1, r.Intn(6) + 1, r.Intn(6) + 1}
+}
+
+// wallStacks returns the number of stacks on each side of a wall of n tiles.
+// Stacks which cannot be divided evenly go to the first sides.
+func wallStacks(n int) [4]int {
+	stacks := (n + 1) / 2
+	var sides [4]int
+	for i := range sides {
+		sides[i] = stacks / 4
+		if i < stacks%4 {
+			sides[i]++
+		}
+	}
+	return sides
+}
+
+// sideOffset returns the position in a wall of n tiles of the first tile on the
+// side in front of a seat.
+func sideOffset(n, seat int) int {
+	stacks := wallStacks(n)
+	offset := 0
+	// the side in front of the first seat is followed by those of the fourth,
+	// third and second seats
+	for side := 0; side != seat; side = (side + 3) % 4 {
+		offset += 2 * stacks[side]
+	}
+	return offset
+}
+
+// breakPoint returns the position in a wall of n tiles where the wall is
+// broken after the dealer rolls the dice. The total of the dice is counted
+// around the table starting from the dealer to pick a side, then the same
+// number of stacks are counted from the right of that side. Drawing starts
+// from the stack after them.
+func breakPoint(n, dealer int, dice []int) int {
+	if n == 0 {
+		return 0
+	}
+	total := 0
+	for _, die := range dice {
+		total += die
+	}
+	side := (dealer + total - 1) % 4
+	return (sideOffset(n, side) + 2*total) % n
+}
+
+// splitWall divides tiles into the live wall and a dead wall of a certain
+// size.
+func (r *Round) splitWall(tiles []Tile, deadWallSize int) {
+	live := len(tiles) - deadWallSize
+	if live < 0 {
+		live = 0
+	}
+	r.Wall = append([]Tile(nil), tiles[:live]...)
+	r.DeadWall = append([]Tile(nil), tiles[live:]...)
+}
+
+// breakWall rearranges the wall so that it starts from the break point
+// determined by the dice.
+func (r *Round) breakWall(dice []int) {
+	tiles := append(append([]Tile(nil), r.Wall...), r.DeadWall...)
+	start := breakPoint(len(tiles), r.Dealer, dice)
+	r.splitWall(append(tiles[start:], tiles[:start]...), len(r.DeadWall))
+}
+
+// drawReplacement removes the last tile from the dead wall, which is then
+// topped up from the end of the live wall.
+func (r *Round) drawReplacement() {
+	r.DeadWall = r.DeadWall[:len(r.DeadWall)-1]
+	if len(r.Wall) > 0 {
+		last := r.Wall[len(r.Wall)-1]
+		r.Wall = r.Wall[:len(r.Wall)-1]
+		r.DeadWall = append([]Tile{last}, r.DeadWall...)
+	}
+}
+
+// nextReplacement returns the next tile to be drawn from the dead wall.
+func (r *Round) nextReplacement() Tile {
+	return r.DeadWall[len(r.DeadWall)-1]
+}
@@ -0,0 +1,69 @@
+package mahjong
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_wallStacks(t *testing.T) {
+	assert.Equal(t, [4]int{19, 19, 18, 18}, wallStacks(148))
+	assert.Equal(t, [4]int{17, 17, 17, 17}, wallStacks(136))
+	assert.Equal(t, [4]int{1, 0, 0, 0}, wallStacks(1))
+}
+
+func Test_breakPoint(t *testing.T) {
+	// sides are laid out in the order of the first, fourth, third and second
+	// seats, with 19, 18, 18 and 19 stacks respectively, so they start at
+	// positions 0, 38, 74 and 110
+	tests := []struct {
+		name   string
+		n      int
+		dealer int
+		dice   []int
+		want   int
+	}{
+		{"dealer's own side", 148, 0, []int{1, 2, 2}, 10},
+		{"side of the player after the dealer", 148, 0, []int{1, 2, 3}, 110 + 6*2},
+		{"counting starts from the dealer", 148, 1, []int{2, 3, 4}, 110 + 9*2},
+		{"side opposite the dealer", 148, 1, []int{6, 6, 6}, 74 + 18*2},
+		// 136 tiles make 17 stacks on each side
+		{"break wraps around", 136, 0, []int{6, 6, 6}, 102 + 18*2 - 136},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, breakPoint(tt.n, tt.dealer, tt.dice))
+		})
+	}
+}
+
+func TestRound_breakWall(t *testing.T) {
+	tiles := make([]Tile, 0, 40)
+	for i := 0; i < 40; i++ {
+		tiles = append(tiles, TileDots1)
+	}
+	// 40 tiles make 5 stacks on each side, so a total of 3 on the dice breaks
+	// the wall 3 stacks into the side of the third seat
+	tiles[20+6] = TileDragonsRed
+	tiles[20+5] = TileDragonsGreen
+	r := &Round{Dealer: 0}
+	r.splitWall(tiles, DeadWallSize)
+	r.breakWall([]int{1, 1, 1})
+	assert.Len(t, r.Wall, 40-DeadWallSize)
+	assert.Len(t, r.DeadWall, DeadWallSize)
+	assert.Equal(t, TileDragonsRed, r.Wall[0])
+	assert.Equal(t, TileDragonsGreen, r.nextReplacement())
+}
+
+func TestRound_drawReplacement(t *testing.T) {
+	r := &Round{
+		Wall:     []Tile{TileDots1, TileDots2},
+		DeadWall: []Tile{TileDots3, TileDots4},
+	}
+	r.drawReplacement()
+	assert.Equal(t, []Tile{TileDots1}, r.Wall)
+	assert.Equal(t, []Tile{TileDots2, TileDots3}, r.DeadWall)
+	r.Wall = nil
+	r.drawReplacement()
+	assert.Equal(t, []Tile{TileDots2}, r.DeadWall)
+}
@@ -1,8 +1,9 @@
 package mahjong
 
 import (
-	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 type searchState struct {
@@ -21,13 +22,32 @@ func (s searchState) copy() searchState {
 		cpy.melds[i].Type = melds.Type
 		cpy.melds[i].Tiles = make([]Tile, len(melds.Tiles))
 		copy(cpy.melds[i].Tiles, melds.Tiles)
+		if melds.Jokers != nil {
+			cpy.melds[i].Jokers = append([]Tile(nil), melds.Jokers...)
+		}
 	}
 	return cpy
 }
 
 func (s searchState) hash() string {
 	sort.Sort(s.melds)
-	return fmt.Sprint(s)
+	var b strings.Builder
+	for _, tile := range sortedTiles(s.tiles) {
+		b.WriteString(string(tile))
+		b.WriteString(strconv.Itoa(s.tiles[tile]))
+	}
+	for _, meld := range s.melds {
+		b.WriteString("|")
+		b.WriteString(strconv.Itoa(int(meld.Type)))
+		for _, tile := range meld.Tiles {
+			b.WriteString(string(tile))
+		}
+		b.WriteString("*")
+		for _, tile := range meld.Jokers {
+			b.WriteString(string(tile))
+		}
+	}
+	return b.String()
 }
 
 func pop(stack []searchState) (searchState, []searchState) {
@@ -38,6 +58,25 @@ func push(stack []searchState, state searchState) []searchState {
 	return append(stack, state)
 }
 
+// cannotWin reports whether tiles without jokers cannot be broken down into
+// melds and an eye, which is much quicker to check than searching for every
+// way to do so.
+func cannotWin(tiles TileBag) bool {
+	counts := countTiles(tiles)
+	if counts.jokers > 0 {
+		return false
+	}
+	n := 0
+	for _, count := range counts.kinds {
+		n += count
+	}
+	if n != tiles.Cardinality() {
+		// leave tiles which are not counted to the search
+		return false
+	}
+	return n%3 != 2 || counts.shanten(n/3) != -1
+}
+
 func search(tiles TileBag, additionalTiles ...Tile) []Melds {
 	var results []Melds
 	seen := make(map[string]struct{})
@@ -45,6 +84,9 @@ func search(tiles TileBag, additionalTiles ...Tile) []Melds {
 	for _, tile := range additionalTiles {
 		initial.tiles.Add(tile)
 	}
+	if cannotWin(initial.tiles) {
+		return nil
+	}
 	stack := []searchState{initial}
 	for len(stack) > 0 {
 		var state searchState
@@ -54,6 +96,7 @@ func search(tiles TileBag, additionalTiles ...Tile) []Melds {
 			continue
 		}
 		seen[hash] = struct{}{}
+		jokers := state.tiles.Count(TileJoker)
 		// check for eyes
 		if len(state.tiles) == 1 {
 			for tile, count := range state.tiles {
@@ -68,8 +111,23 @@ func search(tiles TileBag, additionalTiles ...Tile) []Melds {
 				}
 			}
 		}
-		for tile := range state.tiles {
-			// check for pongs
+		if len(state.tiles) == 2 && jokers == 1 {
+			for _, tile := range sortedTiles(state.tiles) {
+				if tile != TileJoker && state.tiles[tile] == 1 {
+					melds := append(state.melds, Meld{
+						Type:   MeldEyes,
+						Tiles:  []Tile{tile},
+						Jokers: []Tile{tile},
+					})
+					sort.Sort(melds)
+					results = append(results, melds)
+				}
+			}
+		}
+		// tiles are searched in order so that winning hands are always
+		// found in the same order
+		for _, tile := range sortedTiles(state.tiles) {
+			// check for pongs, including a pong of jokers
 			if state.tiles.Count(tile) > 2 {
 				s := state.copy()
 				s.tiles.RemoveN(tile, 3)
@@ -79,24 +137,57 @@ func search(tiles TileBag, additionalTiles ...Tile) []Melds {
 				})
 				stack = push(stack, s)
 			}
+			if tile == TileJoker {
+				continue
+			}
+			// check for pongs with jokers standing in
+			for n := 1; n <= 2 && n <= jokers; n++ {
+				if state.tiles.Count(tile)+n != 3 {
+					continue
+				}
+				s := state.copy()
+				s.tiles.RemoveN(tile, 3-n)
+				s.tiles.RemoveN(TileJoker, n)
+				meld := Meld{
+					Type:  MeldPong,
+					Tiles: []Tile{tile},
+				}
+				for i := 0; i < n; i++ {
+					meld.Jokers = append(meld.Jokers, tile)
+				}
+				s.melds = append(s.melds, meld)
+				stack = push(stack, s)
+			}
 			// check for chi
 			if connecting, ok := sequences[tile]; ok {
 				for _, c := range connecting {
-					if state.tiles.Contains(c[0]) && state.tiles.Contains(c[1]) {
-						s := state.copy()
-						seq := []Tile{tile, c[0], c[1]}
-						sort.Slice(seq, func(i, j int) bool {
-							return seq[i] < seq[j]
-						})
-						s.tiles.Remove(tile)
-						s.tiles.Remove(c[0])
-						s.tiles.Remove(c[1])
-						s.melds = append(s.melds, Meld{
-							Type:  MeldChi,
-							Tiles: seq,
-						})
-						stack = push(stack, s)
+					var missing []Tile
+					for _, other := range c {
+						if !state.tiles.Contains(other) {
+							missing = append(missing, other)
+						}
+					}
+					if len(missing) > jokers {
+						continue
+					}
+					s := state.copy()
+					seq := []Tile{tile, c[0], c[1]}
+					sort.Slice(seq, func(i, j int) bool {
+						return seq[i] < seq[j]
+					})
+					s.tiles.Remove(tile)
+					for _, other := range c {
+						if !contains(missing, other) {
+							s.tiles.Remove(other)
+						}
 					}
+					s.tiles.RemoveN(TileJoker, len(missing))
+					s.melds = append(s.melds, Meld{
+						Type:   MeldChi,
+						Tiles:  seq,
+						Jokers: missing,
+					})
+					stack = push(stack, s)
 				}
 			}
 		}
@@ -158,51 +249,86 @@ func isHalfFlush(suits map[Suit]int) bool {
 	return cardinality == 1
 }
 
-func score(round *Round, seat int, melds Melds) int {
-	score := 0
+// Tai represents an element of a winning hand and how many points it is
+// worth.
+type Tai struct {
+	Name   TaiName `json:"name"`
+	Points int     `json:"points"`
+}
+
+// TaiName identifies an element of a winning hand.
+type TaiName string
+
+// Possible elements of a winning hand.
+const (
+	TaiFullFlush      TaiName = "full_flush"
+	TaiHalfFlush      TaiName = "half_flush"
+	TaiPingHu         TaiName = "ping_hu"
+	TaiChouPingHu     TaiName = "chou_ping_hu"
+	TaiPongPongHu     TaiName = "pong_pong_hu"
+	TaiFlower         TaiName = "flower"
+	TaiDragon         TaiName = "dragon"
+	TaiSeatWind       TaiName = "seat_wind"
+	TaiPrevailingWind TaiName = "prevailing_wind"
+	TaiStreak         TaiName = "streak"
+)
+
+// tai returns the elements of a winning hand which score points.
+func tai(round *Round, seat int, melds Melds) []Tai {
+	var elements []Tai
 	meldTypes := make(map[MeldType]int)
 	suits := make(map[Suit]int)
 	for _, meld := range melds {
 		meldTypes[meld.Type]++
-		suits[meld.Tiles[0].Suit()]++
+		if suit := meld.Tiles[0].Suit(); suit != SuitJokers {
+			suits[suit]++
+		}
 	}
 	if isFullFlush(suits) {
-		score += 4
+		elements = append(elements, Tai{TaiFullFlush, 4})
 	} else if isHalfFlush(suits) {
-		score += 2
+		elements = append(elements, Tai{TaiHalfFlush, 2})
 	}
 	// ping hu
 	if meldTypes[MeldChi] == 4 {
 		// no flowers
 		if len(round.Hands[seat].Flowers) == 0 {
-			return score + 4
+			return append(elements, Tai{TaiPingHu, 4})
 		}
 		// chou ping hu is worth 1 point
-		score += 1
+		elements = append(elements, Tai{TaiChouPingHu, 1})
 	}
 	// pong pong hu
 	if meldTypes[MeldPong]+meldTypes[MeldGang] == 4 {
-		score += 2
+		elements = append(elements, Tai{TaiPongPongHu, 2})
 	}
 	// flowers
 	for _, flower := range round.Hands[seat].Flowers {
 		if isFlowerForSeat(flower, seat) {
-			score++
+			elements = append(elements, Tai{TaiFlower, 1})
 		}
 	}
 	for _, m := range melds {
 		if m.Type == MeldPong || m.Type == MeldGang {
 			if m.Tiles[0] == TileDragonsRed || m.Tiles[0] == TileDragonsGreen || m.Tiles[0] == TileDragonsWhite {
-				score++
+				elements = append(elements, Tai{TaiDragon, 1})
 			}
 			if isMatchingWind(m.Tiles[0], round.seatWind(seat)) {
-				score++
+				elements = append(elements, Tai{TaiSeatWind, 1})
 			}
 			if isMatchingWind(m.Tiles[0], round.Wind) {
-				score++
+				elements = append(elements, Tai{TaiPrevailingWind, 1})
 			}
 		}
 	}
+	return elements
+}
+
+func score(round *Round, seat int, melds Melds) int {
+	score := 0
+	for _, element := range tai(round, seat, melds) {
+		score += element.Points
+	}
 	return score
 }
 
@@ -220,10 +346,93 @@ var (
 type Rules struct {
 	Shooter bool
 	Limit   int
+
+	// Tiles is the set of tiles the wall is built from.
+	Tiles TileSet
+
+	// DealerKeepsOnDraw keeps the same dealer for the next round when a
+	// round ends in a draw.
+	DealerKeepsOnDraw bool
+
+	// StreakPoints are added to the points of the dealer's winning hand for
+	// each consecutive round the dealer has kept.
+	StreakPoints int
+
+	// AbortOnFourKongs ends a round in a draw once four kongs have been
+	// declared by more than one player.
+	AbortOnFourKongs bool
+
+	// AbortOnFourWinds ends a round in a draw when every player discards the
+	// same wind on the first go-around.
+	AbortOnFourWinds bool
+
+	// AbortOnNineTerminals ends a round in a draw when a player is dealt nine
+	// or more different terminals and honours.
+	AbortOnNineTerminals bool
+
+	// FalseWin determines what happens when a player declares a win without
+	// a winning hand (诈胡).
+	FalseWin FalseWinRule
+
+	// FalseWinPenalty is paid by a player to each other player for a false
+	// win.
+	FalseWinPenalty int
+
+	// Liability makes a player who fed the meld completing a big hand pay
+	// for everyone when it wins (包).
+	Liability bool
+
+	// SacredDiscard stops a player from winning off a discard of a tile they
+	// already passed on winning with, until they next draw or claim a tile.
+	SacredDiscard bool
 }
 
-// winnings returns how much each player's score changes.
-func winnings(rules Rules, winner, loser, points int) [4]int {
+// FalseWinRule represents how a false win is penalised.
+type FalseWinRule string
+
+// Possible false win rules.
+const (
+	// FalseWinAllowed lets a player declare a win without a winning hand
+	// without penalty. The declaration simply fails.
+	FalseWinAllowed FalseWinRule = ""
+
+	// FalseWinExposed makes a player pay the penalty and play on with their
+	// concealed tiles shown to everyone.
+	FalseWinExposed FalseWinRule = "exposed"
+
+	// FalseWinDeadHand makes a player pay the penalty and play on without
+	// being able to win for the rest of the round.
+	FalseWinDeadHand FalseWinRule = "dead_hand"
+)
+
+// liablePlayer returns the integer offset of the player who discarded the tile
+// claimed for a hand's third dragon or fourth wind pong or gang, or -1 if there
+// is none.
+func liablePlayer(revealed Melds) int {
+	dragons, winds := 0, 0
+	for _, meld := range revealed {
+		if meld.Type != MeldPong && meld.Type != MeldGang {
+			continue
+		}
+		switch meld.Tiles[0].Suit() {
+		case SuitDragons:
+			dragons++
+			if dragons == 3 && meld.Feeder != nil {
+				return *meld.Feeder
+			}
+		case SuitWinds:
+			winds++
+			if winds == 4 && meld.Feeder != nil {
+				return *meld.Feeder
+			}
+		}
+	}
+	return -1
+}
+
+// winnings returns how much each player's score changes. If a player is liable
+// for the winning hand, they pay what everyone else would have.
+func winnings(rules Rules, winner, loser, liable, points int) [4]int {
 	limit := rules.Limit
 	if limit == 0 {
 		limit = 5
@@ -259,5 +468,11 @@ func winnings(rules Rules, winner, loser, points int) [4]int {
 			}
 		}
 	}
+	if liable != -1 && liable != winner {
+		total := deltas[winner]
+		deltas = [4]int{}
+		deltas[winner] = total
+		deltas[liable] = -total
+	}
 	return deltas
 }
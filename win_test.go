@@ -30,6 +30,19 @@ func Test_search(t *testing.T) {
 			},
 		}, result)
 	})
+	t.Run("winning combinations are found in the same order every time", func(t *testing.T) {
+		tiles := NewTileBag([]Tile{
+			TileDots1, TileDots1, TileDots1,
+			TileDots2, TileDots2, TileDots2,
+			TileDots3, TileDots3, TileDots3,
+			TileDots4, TileDots4, TileDots4,
+			TileDots5, TileDots5,
+		})
+		first := search(tiles)
+		for i := 0; i < 20; i++ {
+			assert.Equal(t, first, search(tiles))
+		}
+	})
 	t.Run("hand with odd number of tiles", func(t *testing.T) {
 		tiles := NewTileBag([]Tile{
 			TileDots1, TileDots1, TileDots1,
@@ -47,6 +60,26 @@ func Test_search(t *testing.T) {
 		result := search(tiles)
 		assert.Equal(t, []Melds{{{Type: MeldEyes, Tiles: []Tile{"46白板"}}}}, result)
 	})
+	t.Run("jokers completing melds", func(t *testing.T) {
+		tiles := NewTileBag([]Tile{
+			TileDots1, TileDots3, TileJoker,
+			TileDragonsRed, TileDragonsRed, TileJoker,
+			TileDragonsWhite,
+		})
+		result := search(tiles, TileJoker)
+		assert.Contains(t, result, Melds{
+			{Type: MeldChi, Tiles: []Tile{"13一筒", "14二筒", "15三筒"}, Jokers: []Tile{"14二筒"}},
+			{Type: MeldPong, Tiles: []Tile{"44红中"}, Jokers: []Tile{"44红中"}},
+			{Type: MeldEyes, Tiles: []Tile{"46白板"}, Jokers: []Tile{"46白板"}},
+		})
+	})
+	t.Run("jokers without a win", func(t *testing.T) {
+		tiles := NewTileBag([]Tile{
+			TileDots1, TileDots5, TileJoker,
+			TileDragonsWhite, TileDragonsRed,
+		})
+		assert.Empty(t, search(tiles))
+	})
 }
 
 func Benchmark_search(b *testing.B) {
@@ -244,38 +277,132 @@ func Test_score(t *testing.T) {
 func Test_winnings(t *testing.T) {
 	t.Run("default rules", func(t *testing.T) {
 		rules := RulesDefault
-		actual := winnings(rules, 0, 2, 3)
+		actual := winnings(rules, 0, 2, -1, 3)
 		expected := [4]int{16, -4, -8, -4}
 		assert.Equal(t, expected, actual)
 	})
 	t.Run("default rules, zi mo", func(t *testing.T) {
 		rules := RulesDefault
-		actual := winnings(rules, 0, -1, 3)
+		actual := winnings(rules, 0, -1, -1, 3)
 		expected := [4]int{24, -8, -8, -8}
 		assert.Equal(t, expected, actual)
 	})
 	t.Run("shooter pays", func(t *testing.T) {
 		rules := RulesShooter
-		actual := winnings(rules, 0, 2, 3)
+		actual := winnings(rules, 0, 2, -1, 3)
 		expected := [4]int{16, 0, -16, 0}
 		assert.Equal(t, expected, actual)
 	})
 	t.Run("shooter pays, zi mo", func(t *testing.T) {
 		rules := RulesShooter
-		actual := winnings(rules, 0, -1, 3)
+		actual := winnings(rules, 0, -1, -1, 3)
 		expected := [4]int{24, -8, -8, -8}
 		assert.Equal(t, expected, actual)
 	})
 	t.Run("default rules, limit", func(t *testing.T) {
 		rules := RulesDefault
-		actual := winnings(rules, 0, 2, 8)
+		actual := winnings(rules, 0, 2, -1, 8)
 		expected := [4]int{64, -16, -32, -16}
 		assert.Equal(t, expected, actual)
 	})
 	t.Run("shooter pays, limit", func(t *testing.T) {
 		rules := RulesShooter
-		actual := winnings(rules, 0, 2, 8)
+		actual := winnings(rules, 0, 2, -1, 8)
 		expected := [4]int{64, 0, -64, 0}
 		assert.Equal(t, expected, actual)
 	})
+	t.Run("liable player pays", func(t *testing.T) {
+		rules := RulesDefault
+		actual := winnings(rules, 0, 2, 1, 3)
+		expected := [4]int{16, -16, 0, 0}
+		assert.Equal(t, expected, actual)
+	})
+	t.Run("liable player pays, zi mo", func(t *testing.T) {
+		rules := RulesDefault
+		actual := winnings(rules, 0, -1, 3, 3)
+		expected := [4]int{24, 0, 0, -24}
+		assert.Equal(t, expected, actual)
+	})
+}
+
+func Test_liablePlayer(t *testing.T) {
+	seat1, seat2 := 1, 2
+	t.Run("third dragon", func(t *testing.T) {
+		revealed := Melds{
+			{Type: MeldPong, Tiles: []Tile{TileDragonsRed}, Feeder: &seat1},
+			{Type: MeldChi, Tiles: []Tile{TileDots1, TileDots2, TileDots3}, Feeder: &seat2},
+			{Type: MeldGang, Tiles: []Tile{TileDragonsGreen}},
+			{Type: MeldPong, Tiles: []Tile{TileDragonsWhite}, Feeder: &seat2},
+		}
+		assert.Equal(t, 2, liablePlayer(revealed))
+	})
+	t.Run("fourth wind", func(t *testing.T) {
+		revealed := Melds{
+			{Type: MeldPong, Tiles: []Tile{TileWindsEast}},
+			{Type: MeldPong, Tiles: []Tile{TileWindsSouth}, Feeder: &seat2},
+			{Type: MeldGang, Tiles: []Tile{TileWindsWest}},
+			{Type: MeldPong, Tiles: []Tile{TileWindsNorth}, Feeder: &seat1},
+		}
+		assert.Equal(t, 1, liablePlayer(revealed))
+	})
+	t.Run("third dragon from own tiles", func(t *testing.T) {
+		revealed := Melds{
+			{Type: MeldPong, Tiles: []Tile{TileDragonsRed}, Feeder: &seat1},
+			{Type: MeldPong, Tiles: []Tile{TileDragonsGreen}, Feeder: &seat1},
+			{Type: MeldGang, Tiles: []Tile{TileDragonsWhite}},
+		}
+		assert.Equal(t, -1, liablePlayer(revealed))
+	})
+	t.Run("two dragons", func(t *testing.T) {
+		revealed := Melds{
+			{Type: MeldPong, Tiles: []Tile{TileDragonsRed}, Feeder: &seat1},
+			{Type: MeldPong, Tiles: []Tile{TileDragonsGreen}, Feeder: &seat1},
+		}
+		assert.Equal(t, -1, liablePlayer(revealed))
+	})
+}
+
+func Test_searchState_hash(t *testing.T) {
+	chi := Meld{Type: MeldChi, Tiles: []Tile{TileDots1, TileDots2, TileDots3}}
+	pong := Meld{Type: MeldPong, Tiles: []Tile{TileDots1}}
+	tiles := NewTileBag([]Tile{TileDragonsWhite, TileDragonsWhite})
+	t.Run("same for melds in any order", func(t *testing.T) {
+		a := searchState{tiles: tiles, melds: Melds{pong, chi}}
+		b := searchState{tiles: tiles, melds: Melds{chi, pong}}
+		assert.Equal(t, a.hash(), b.hash())
+	})
+	t.Run("different for different jokers", func(t *testing.T) {
+		withJoker := chi
+		withJoker.Jokers = []Tile{TileDots2}
+		a := searchState{tiles: tiles, melds: Melds{chi}}
+		b := searchState{tiles: tiles, melds: Melds{withJoker}}
+		assert.NotEqual(t, a.hash(), b.hash())
+	})
+	t.Run("different for different tiles", func(t *testing.T) {
+		a := searchState{tiles: tiles, melds: Melds{chi}}
+		b := searchState{tiles: NewTileBag([]Tile{TileDragonsWhite}), melds: Melds{chi}}
+		assert.NotEqual(t, a.hash(), b.hash())
+	})
+}
+
+func Test_cannotWin(t *testing.T) {
+	tests := []struct {
+		name  string
+		tiles []Tile
+		want  bool
+	}{
+		{"winning hand", []Tile{TileDots1, TileDots2, TileDots3, TileDragonsWhite, TileDragonsWhite}, false},
+		{"wrong number of tiles", []Tile{TileDots1, TileDots2, TileDots3, TileDragonsWhite}, true},
+		{"no eye", []Tile{TileDots1, TileDots2, TileDots3, TileDragonsWhite, TileDragonsRed}, true},
+		{"jokers are left to the search", []Tile{TileDots1, TileDots5, TileJoker, TileDragonsWhite, TileDragonsRed}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiles := NewTileBag(tt.tiles)
+			assert.Equal(t, tt.want, cannotWin(tiles))
+			if tt.want {
+				assert.Empty(t, search(tiles))
+			}
+		})
+	}
 }